@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/app/client"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/certs"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/config"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/log"
+	"github.com/pvarentsov/powtcp/internal/pkg/service"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// logLevelOff - level above error, probe logs nothing with it
+const logLevelOff = int(log.LevelError) + 4
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var (
+		opts     runOpts
+		names    string
+		list     bool
+		jsonOut  bool
+		logLevel int
+		selected []scenario
+	)
+	flag.StringVar(&names, "scenario", "all", "comma separated scenarios to run or all")
+	flag.BoolVar(&list, "list", false, "list scenarios and exit")
+	flag.IntVar(&opts.Concurrency, "concurrency", 50, "attacking workers per scenario")
+	flag.DurationVar(&opts.Duration, "duration", 10*time.Second, "duration of each scenario")
+	flag.DurationVar(&opts.Warmup, "warmup", time.Second, "delay before probing in each scenario")
+	flag.DurationVar(&opts.Cooldown, "cooldown", 2*time.Second, "pause between scenarios")
+	flag.DurationVar(&opts.ProbeInterval, "probe-interval", 200*time.Millisecond, "interval between probe fetches")
+	flag.DurationVar(&opts.ProbeTimeout, "probe-timeout", 5*time.Second, "max duration of probe fetch")
+	flag.Float64Var(&opts.MinSuccess, "min-success", 0.9, "min part of successful probe fetches to pass")
+	flag.BoolVar(&jsonOut, "json", false, "print report in json")
+	flag.IntVar(&logLevel, "log-level", logLevelOff, "log level of probe client, off by default")
+
+	// Server settings are taken from client config
+	config, err := config.Parse("config")
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if list {
+		listScenarios()
+		return
+	}
+
+	selected, err = selectScenarios(names)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if opts.Concurrency <= 0 {
+		fmt.Println("concurrency must be more than zero")
+		os.Exit(1)
+	}
+	opts.MaxAttempts = config.Hashcash.ComputeMaxAttempts
+
+	logger := log.New(log.Opts{
+		Level: log.Level(logLevel),
+		Json:  config.Client.LogJson,
+	})
+
+	var tlsConfig *tls.Config
+	if config.Client.TLSEnabled {
+		tlsConfig, err = certs.NewClientConfig(ctx, certs.ClientOpts{
+			CAFile:     config.Client.TLSCAFile,
+			CertFile:   config.Client.TLSCertFile,
+			KeyFile:    config.Client.TLSKeyFile,
+			ServerName: config.Client.TLSServerName,
+			Logger:     logger,
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	t := &target{
+		network:   config.Client.ServerNetwork,
+		address:   config.Client.ServerAddress,
+		tlsConfig: tlsConfig,
+	}
+
+	clientOpts := client.Opts{
+		Config: newConfigClient(config),
+		Logger: logger,
+		Service: service.NewClient(service.ClientOpts{
+			Config: newConfigService(config),
+			Logger: logger,
+			Tracer: noop.NewTracerProvider().Tracer(""),
+		}),
+		TLSConfig: tlsConfig,
+	}
+
+	var results []result
+	for i, s := range selected {
+		if ctx.Err() != nil {
+			break
+		}
+		if i > 0 {
+			sleep(ctx, opts.Cooldown)
+		}
+
+		logger.Info("scenario started", "scenario", s.name)
+		results = append(results, runScenario(ctx, s, opts, t, clientOpts))
+	}
+
+	if jsonOut {
+		err = writeJSON(os.Stdout, results)
+	} else {
+		err = writeText(os.Stdout, results)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	for _, r := range results {
+		if !r.Pass {
+			os.Exit(2)
+		}
+	}
+}
+
+// selectScenarios - returns scenarios by comma separated names or all of them
+func selectScenarios(names string) ([]scenario, error) {
+	if names == "all" || names == "" {
+		return scenarios, nil
+	}
+
+	var selected []scenario
+	for _, name := range strings.Split(names, ",") {
+		s, ok := findScenario(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown scenario: %s", name)
+		}
+		selected = append(selected, s)
+	}
+
+	return selected, nil
+}
+
+func listScenarios() {
+	for _, s := range scenarios {
+		fmt.Printf("%-14s %s\n", s.name, s.description)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/app/client"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/histogram"
+)
+
+// probe - legitimate client measuring server responsiveness during attack
+type probe struct {
+	client   client.Opts
+	interval time.Duration
+	timeout  time.Duration
+
+	sent    int
+	ok      int
+	errs    map[string]int
+	latency *histogram.Histogram
+}
+
+func newProbe(opts client.Opts, interval time.Duration, timeout time.Duration) *probe {
+	return &probe{
+		client:   opts,
+		interval: interval,
+		timeout:  timeout,
+		errs:     make(map[string]int),
+		latency:  histogram.New(nil),
+	}
+}
+
+// run - fetch resource every interval until context is done
+// Fetch that doesn't finish within timeout fails
+func (p *probe) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fetchCtx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		start := time.Now()
+		_, err := client.Connect(fetchCtx, p.client)
+		cancel()
+
+		p.sent++
+		if err != nil {
+			p.errs[errorReason(err)]++
+			continue
+		}
+
+		p.ok++
+		p.latency.Record(time.Since(start))
+	}
+}
+
+// successRatio - returns part of successful fetches
+func (p *probe) successRatio() float64 {
+	if p.sent == 0 {
+		return 0
+	}
+	return float64(p.ok) / float64(p.sent)
+}
+
+// errorReason - returns error text without connection addresses to group errors
+func errorReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "probe timeout exceeded"
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op + ": " + opErr.Err.Error()
+	}
+
+	return err.Error()
+}
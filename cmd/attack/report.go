@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// writeJSON - write results as indented json
+func writeJSON(w io.Writer, results []result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+// writeText - write results as table followed by probe errors of failed scenarios
+func writeText(w io.Writer, results []result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "scenario\tresult\tconnections\tsent\tprobes\tsuccess\tp50\tp99\n")
+	for _, r := range results {
+		status := "PASS"
+		if !r.Pass {
+			status = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d/%d\t%.2f\t%.2fms\t%.2fms\n",
+			r.Scenario, status, r.Connections, r.Sent, r.ProbeOK, r.ProbeSent, r.SuccessRatio, r.ProbeP50Ms, r.ProbeP99Ms)
+	}
+
+	for _, r := range results {
+		if r.Pass {
+			continue
+		}
+
+		fmt.Fprintf(tw, "\n%s: %s\n", r.Scenario, r.Reason)
+
+		reasons := make([]string, 0, len(r.ProbeErrors))
+		for reason := range r.ProbeErrors {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool { return r.ProbeErrors[reasons[i]] > r.ProbeErrors[reasons[j]] })
+
+		for _, reason := range reasons {
+			fmt.Fprintf(tw, "  %s\t%d\n", reason, r.ProbeErrors[reason])
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/app/client"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/histogram"
+)
+
+// runOpts - options of scenario run
+// MinSuccess - min part of successful probe fetches for scenario to pass
+type runOpts struct {
+	Concurrency   int
+	Duration      time.Duration
+	Warmup        time.Duration
+	Cooldown      time.Duration
+	ProbeInterval time.Duration
+	ProbeTimeout  time.Duration
+	MinSuccess    float64
+	MaxAttempts   int
+}
+
+// runScenario - attack server and probe it with legitimate client after warmup
+func runScenario(ctx context.Context, s scenario, opts runOpts, t *target, clientOpts client.Opts) result {
+	attackCtx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	a := &attacker{
+		target:      t,
+		maxAttempts: opts.MaxAttempts,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.attack(attackCtx, a)
+		}()
+	}
+
+	p := newProbe(clientOpts, opts.ProbeInterval, opts.ProbeTimeout)
+
+	sleep(attackCtx, opts.Warmup)
+	p.run(attackCtx)
+
+	wg.Wait()
+
+	return newResult(s, opts, a, p)
+}
+
+// result - outcome of scenario
+type result struct {
+	Scenario     string         `json:"scenario"`
+	Description  string         `json:"description"`
+	Pass         bool           `json:"pass"`
+	Reason       string         `json:"reason,omitempty"`
+	Connections  int64          `json:"connections"`
+	DialErrors   int64          `json:"dial_errors"`
+	Sent         int64          `json:"sent"`
+	Accepted     int64          `json:"accepted"`
+	ProbeSent    int            `json:"probe_sent"`
+	ProbeOK      int            `json:"probe_ok"`
+	SuccessRatio float64        `json:"success_ratio"`
+	ProbeP50Ms   float64        `json:"probe_p50_ms"`
+	ProbeP99Ms   float64        `json:"probe_p99_ms"`
+	ProbeErrors  map[string]int `json:"probe_errors"`
+}
+
+func newResult(s scenario, opts runOpts, a *attacker, p *probe) result {
+	latency := p.latency.Snapshot()
+
+	r := result{
+		Scenario:     s.name,
+		Description:  s.description,
+		Connections:  a.connections.Load(),
+		DialErrors:   a.dialErrors.Load(),
+		Sent:         a.sent.Load(),
+		Accepted:     a.accepted.Load(),
+		ProbeSent:    p.sent,
+		ProbeOK:      p.ok,
+		SuccessRatio: p.successRatio(),
+		ProbeP50Ms:   histogram.Milliseconds(latency.P50),
+		ProbeP99Ms:   histogram.Milliseconds(latency.P99),
+		ProbeErrors:  p.errs,
+	}
+
+	switch {
+	case r.ProbeSent == 0:
+		r.Reason = "no probes sent, duration is shorter than warmup"
+	case r.Accepted > 0:
+		r.Reason = fmt.Sprintf("%d replayed solutions accepted", r.Accepted)
+	case r.SuccessRatio < opts.MinSuccess:
+		r.Reason = fmt.Sprintf("probe success ratio %.2f is below %.2f", r.SuccessRatio, opts.MinSuccess)
+	default:
+		r.Pass = true
+	}
+
+	return r
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+)
+
+var errPuzzleSkipped = errors.New("server skipped proof of work, nothing to replay")
+
+const (
+	slowlorisInterval = time.Second
+	oversizedChunk    = 64 * 1024
+	oversizedLimit    = 64 * 1024 * 1024
+	garbageChunk      = 4 * 1024
+	connFloodLimit    = 1000
+)
+
+// scenario - named abuse pattern
+// Attack is run by every worker until context is done
+type scenario struct {
+	name        string
+	description string
+	attack      func(ctx context.Context, a *attacker)
+}
+
+var scenarios = []scenario{
+	{
+		name:        "puzzle-flood",
+		description: "request puzzles without solving them",
+		attack:      puzzleFlood,
+	},
+	{
+		name:        "slowloris",
+		description: "hold connections sending a partial line byte by byte",
+		attack:      slowloris,
+	},
+	{
+		name:        "oversized",
+		description: "send endless message without delimiter",
+		attack:      oversized,
+	},
+	{
+		name:        "replay",
+		description: "redeem solved puzzle again on new connections",
+		attack:      replay,
+	},
+	{
+		name:        "garbage",
+		description: "send random bytes instead of messages",
+		attack:      garbage,
+	},
+	{
+		name:        "conn-flood",
+		description: "open idle connections and hold them",
+		attack:      connFlood,
+	},
+}
+
+// findScenario - returns scenario by name
+func findScenario(name string) (scenario, bool) {
+	for _, s := range scenarios {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return scenario{}, false
+}
+
+// attacker - shared state of scenario workers
+type attacker struct {
+	target      *target
+	maxAttempts int
+
+	connections atomic.Int64
+	dialErrors  atomic.Int64
+	sent        atomic.Int64
+	accepted    atomic.Int64
+	open        atomic.Int64
+}
+
+// connect - dial server and count connection
+func (a *attacker) connect(ctx context.Context) (net.Conn, bool) {
+	conn, err := a.target.dial(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			a.dialErrors.Add(1)
+			sleep(ctx, 10*time.Millisecond)
+		}
+		return nil, false
+	}
+
+	a.connections.Add(1)
+	return conn, true
+}
+
+// closeOnDone - close connection when context is done to unblock writes and reads
+func closeOnDone(ctx context.Context, conn net.Conn) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func puzzleFlood(ctx context.Context, a *attacker) {
+	for ctx.Err() == nil {
+		conn, ok := a.connect(ctx)
+		if !ok {
+			continue
+		}
+
+		if _, err := request(conn, message.Message{Command: message.CommandRequestPuzzle}); err == nil {
+			a.sent.Add(1)
+		}
+		conn.Close()
+	}
+}
+
+func slowloris(ctx context.Context, a *attacker) {
+	for ctx.Err() == nil {
+		conn, ok := a.connect(ctx)
+		if !ok {
+			continue
+		}
+
+		stop := closeOnDone(ctx, conn)
+		line := []byte("1:")
+		for ctx.Err() == nil {
+			if _, err := conn.Write(line); err != nil {
+				break
+			}
+			a.sent.Add(1)
+			line = []byte("a")
+			sleep(ctx, slowlorisInterval)
+		}
+		stop()
+		conn.Close()
+	}
+}
+
+func oversized(ctx context.Context, a *attacker) {
+	chunk := bytes.Repeat([]byte("a"), oversizedChunk)
+
+	for ctx.Err() == nil {
+		conn, ok := a.connect(ctx)
+		if !ok {
+			continue
+		}
+
+		stop := closeOnDone(ctx, conn)
+		if _, err := conn.Write([]byte("3:")); err == nil {
+			for written := 0; written < oversizedLimit && ctx.Err() == nil; written += len(chunk) {
+				if _, err := conn.Write(chunk); err != nil {
+					break
+				}
+				a.sent.Add(1)
+			}
+		}
+		stop()
+		conn.Close()
+	}
+}
+
+func replay(ctx context.Context, a *attacker) {
+	solution, ok := a.solve(ctx)
+	if !ok {
+		return
+	}
+
+	for ctx.Err() == nil {
+		conn, ok := a.connect(ctx)
+		if !ok {
+			continue
+		}
+
+		reply, err := request(conn, message.Message{Command: message.CommandRequestResource, Payload: solution})
+		if err == nil {
+			a.sent.Add(1)
+			if reply.Command == message.CommandResponseResource {
+				a.accepted.Add(1)
+			}
+		}
+		conn.Close()
+	}
+}
+
+// solve - pass proof of work honestly and returns solved puzzle to replay
+func (a *attacker) solve(ctx context.Context) (string, bool) {
+	for ctx.Err() == nil {
+		conn, ok := a.connect(ctx)
+		if !ok {
+			continue
+		}
+
+		solution, err := a.solveOnce(ctx, conn)
+		conn.Close()
+		if err == nil {
+			return solution, true
+		}
+		sleep(ctx, 100*time.Millisecond)
+	}
+
+	return "", false
+}
+
+func (a *attacker) solveOnce(ctx context.Context, conn net.Conn) (string, error) {
+	reply, err := request(conn, message.Message{Command: message.CommandRequestPuzzle})
+	if err != nil {
+		return "", err
+	}
+	if reply.Command != message.CommandResponsePuzzle || reply.Payload == "" {
+		return "", errPuzzleSkipped
+	}
+
+	puzzle, err := hashcash.ParseHeader(reply.Payload)
+	if err != nil {
+		return "", err
+	}
+	if err := puzzle.ComputeContext(ctx, a.maxAttempts); err != nil {
+		return "", err
+	}
+
+	solution := string(puzzle.Header())
+	reply, err = request(conn, message.Message{Command: message.CommandRequestResource, Payload: solution})
+	if err != nil {
+		return "", err
+	}
+	if reply.Command != message.CommandResponseResource {
+		return "", errors.New(reply.Payload)
+	}
+
+	return solution, nil
+}
+
+func garbage(ctx context.Context, a *attacker) {
+	chunk := make([]byte, garbageChunk)
+
+	for ctx.Err() == nil {
+		conn, ok := a.connect(ctx)
+		if !ok {
+			continue
+		}
+
+		stop := closeOnDone(ctx, conn)
+		conn.SetDeadline(time.Now().Add(replyTimeout))
+		for ctx.Err() == nil {
+			rand.Read(chunk)
+			if _, err := conn.Write(chunk); err != nil {
+				break
+			}
+			a.sent.Add(1)
+		}
+		stop()
+		conn.Close()
+	}
+}
+
+func connFlood(ctx context.Context, a *attacker) {
+	for ctx.Err() == nil {
+		if a.open.Load() >= connFloodLimit {
+			sleep(ctx, 10*time.Millisecond)
+			continue
+		}
+
+		conn, ok := a.connect(ctx)
+		if !ok {
+			continue
+		}
+		a.sent.Add(1)
+		a.open.Add(1)
+
+		// Connection is held until server closes it or scenario ends
+		go func() {
+			defer a.open.Add(-1)
+			defer conn.Close()
+
+			stop := closeOnDone(ctx, conn)
+			defer stop()
+
+			io.Copy(io.Discard, conn)
+		}()
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
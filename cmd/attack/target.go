@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/websocket"
+)
+
+const (
+	networkWebSocket = "ws"
+	replyTimeout     = 5 * time.Second
+)
+
+// target - server under attack
+type target struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+}
+
+// dial - open raw connection to server, over tls if it's configured
+func (t *target) dial(ctx context.Context) (net.Conn, error) {
+	if t.network == networkWebSocket {
+		return websocket.Dial(ctx, t.address, t.tlsConfig)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, t.network, t.address)
+	if err != nil {
+		return nil, err
+	}
+	if t.tlsConfig == nil {
+		return conn, nil
+	}
+
+	config := t.tlsConfig
+	if config.ServerName == "" {
+		if host, _, err := net.SplitHostPort(t.address); err == nil {
+			config = config.Clone()
+			config.ServerName = host
+		}
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// request - send message and read reply within replyTimeout
+func request(conn net.Conn, msg message.Message) (message.Message, error) {
+	conn.SetDeadline(time.Now().Add(replyTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	if _, err := conn.Write(msg.Bytes()); err != nil {
+		return message.Message{}, err
+	}
+
+	raw, err := message.ReadMessage(conn)
+	if err != nil {
+		return message.Message{}, err
+	}
+
+	return message.ParseMessage(raw)
+}
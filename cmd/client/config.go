@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/config"
 )
 
@@ -18,6 +20,10 @@ func (cc *configClient) ServerAddress() string {
 	return cc.c.Client.ServerAddress
 }
 
+func (cc *configClient) ServerNetwork() string {
+	return cc.c.Client.ServerNetwork
+}
+
 func newConfigService(c *config.Config) *configService {
 	return &configService{
 		c: c,
@@ -31,3 +37,23 @@ type configService struct {
 func (cs *configService) PuzzleComputeMaxAttempts() int {
 	return cs.c.Hashcash.ComputeMaxAttempts
 }
+
+func (cs *configService) PuzzleMaxZeroBits() int {
+	return cs.c.Hashcash.MaxBits
+}
+
+func (cs *configService) PuzzleSolveBudget() time.Duration {
+	return time.Duration(cs.c.Hashcash.SolveBudget) * time.Millisecond
+}
+
+func (cs *configService) APIKey() string {
+	return cs.c.Client.APIKey
+}
+
+func (cs *configService) Identity() string {
+	return cs.c.Client.Identity
+}
+
+func (cs *configService) TracePropagation() bool {
+	return cs.c.Trace.Propagate
+}
@@ -1,16 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/pvarentsov/powtcp/internal/app/client"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/certs"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/config"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/log"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/retry"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/trace"
 	"github.com/pvarentsov/powtcp/internal/pkg/service"
 )
 
 func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
 	config, err := config.Parse("config")
 	if err != nil {
 		fmt.Println(err.Error())
@@ -25,23 +37,102 @@ func main() {
 		Json:  config.Client.LogJson,
 	})
 
+	tracerProvider, err := trace.New(ctx, trace.Opts{
+		ServiceName: "powtcp-client",
+		Exporter:    trace.Exporter(config.Trace.Exporter),
+		Endpoint:    config.Trace.Endpoint,
+		Insecure:    config.Trace.Insecure,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
 	service := service.NewClient(service.ClientOpts{
 		Config: configService,
 		Logger: logger,
+		Tracer: tracerProvider.Tracer("github.com/pvarentsov/powtcp/service"),
 	})
 
 	logger.Debug("client configured",
 		"server_address", configClient.ServerAddress(),
 		"puzzle_compute_max_attempts", configService.PuzzleComputeMaxAttempts(),
+		"puzzle_max_zero_bits", configService.PuzzleMaxZeroBits(),
+		"puzzle_solve_budget", configService.PuzzleSolveBudget(),
+		"forward_address", config.Client.ForwardAddress,
+		"retry_max_attempts", config.Client.RetryMaxAttempts,
+		"retry_on", config.Client.RetryOn,
+		"tls_enabled", config.Client.TLSEnabled,
+		"trace_exporter", config.Trace.Exporter,
+		"trace_propagation", configService.TracePropagation(),
 	)
 
-	err = client.Connect(client.Opts{
-		Config:  configClient,
-		Logger:  logger,
-		Service: service,
+	var tlsConfig *tls.Config
+	if config.Client.TLSEnabled {
+		tlsConfig, err = certs.NewClientConfig(ctx, certs.ClientOpts{
+			CAFile:         config.Client.TLSCAFile,
+			CertFile:       config.Client.TLSCertFile,
+			KeyFile:        config.Client.TLSKeyFile,
+			ServerName:     config.Client.TLSServerName,
+			ReloadInterval: time.Duration(config.Client.TLSReloadInterval) * time.Millisecond,
+			Logger:         logger,
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	retryable, err := client.Retryable(config.Client.RetryOn)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	retryPolicy := retry.New(retry.Opts{
+		MaxAttempts:    config.Client.RetryMaxAttempts,
+		InitialBackoff: time.Duration(config.Client.RetryInitialBackoff) * time.Millisecond,
+		MaxBackoff:     time.Duration(config.Client.RetryMaxBackoff) * time.Millisecond,
+		Jitter:         config.Client.RetryJitter,
+		Retryable:      retryable,
+		Logger:         logger,
 	})
+
+	opts := client.Opts{
+		Config:    configClient,
+		Logger:    logger,
+		Service:   service,
+		TLSConfig: tlsConfig,
+		Retry:     retryPolicy,
+	}
+
+	if config.Client.ForwardAddress != "" {
+		err = forward(ctx, opts, config.Client.ForwardAddress)
+	} else {
+		_, err = client.Connect(ctx, opts)
+	}
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), time.Second)
+	defer flushCancel()
+
+	if err := tracerProvider.Shutdown(flushCtx); err != nil {
+		logger.Error(err.Error(), "op", "main")
+	}
+
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 }
+
+// forward - listen local address and forward its connections to server until context is done
+func forward(ctx context.Context, opts client.Opts, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	opts.Logger.Info("forwarding started", "address", listener.Addr().String())
+
+	return client.Forward(ctx, opts, listener)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+)
+
+// algorithm - proof of work algorithm to benchmark
+type algorithm struct {
+	name             string
+	maxBits          int
+	hashRate         func(ctx context.Context, duration time.Duration) (float64, error)
+	expectedAttempts func(bits int) float64
+	quantileAttempts func(bits int, q float64) float64
+	bitsForSolveTime func(rate float64, target time.Duration) (int, error)
+}
+
+var algorithms = []algorithm{
+	{
+		name:             "sha1",
+		maxBits:          hashcash.MaxBits,
+		hashRate:         hashcash.HashRate,
+		expectedAttempts: hashcash.ExpectedAttempts,
+		quantileAttempts: hashcash.QuantileAttempts,
+		bitsForSolveTime: hashcash.BitsForSolveTime,
+	},
+}
+
+// benchResult - measured hash rate and solve times of algorithm
+// RecommendedBits - bits for target median solve time, zero if target isn't set
+type benchResult struct {
+	Algorithm         string       `json:"algorithm"`
+	AttemptsPerSecond float64      `json:"attempts_per_second"`
+	RecommendedBits   int          `json:"recommended_bits,omitempty"`
+	Levels            []benchLevel `json:"levels"`
+}
+
+// benchLevel - expected solve times of difficulty level in seconds
+type benchLevel struct {
+	Bits     int     `json:"bits"`
+	Attempts float64 `json:"attempts"`
+	Median   float64 `json:"median_seconds"`
+	Mean     float64 `json:"mean_seconds"`
+	P90      float64 `json:"p90_seconds"`
+	P99      float64 `json:"p99_seconds"`
+}
+
+// bench - measure hash rate of each algorithm and print expected solve times
+func bench(ctx context.Context, args []string) error {
+	var (
+		duration time.Duration
+		minBits  int
+		maxBits  int
+		target   time.Duration
+		jsonOut  bool
+	)
+
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	flags.DurationVar(&duration, "duration", time.Second, "hash rate measuring duration of each algorithm")
+	flags.IntVar(&minBits, "min-bits", 1, "min difficulty level to print")
+	flags.IntVar(&maxBits, "max-bits", 8, "max difficulty level to print")
+	flags.DurationVar(&target, "target", 0, "recommend bits for this median solve time")
+	flags.BoolVar(&jsonOut, "json", false, "print report in json")
+	flags.Parse(args)
+
+	if minBits <= 0 || maxBits < minBits {
+		return fmt.Errorf("incorrect difficulty levels: %d-%d", minBits, maxBits)
+	}
+
+	results := make([]benchResult, 0, len(algorithms))
+	for _, a := range algorithms {
+		result, err := benchAlgorithm(ctx, a, duration, minBits, maxBits, target)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	if jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	return writeBench(os.Stdout, results, target)
+}
+
+func benchAlgorithm(ctx context.Context, a algorithm, duration time.Duration, minBits, maxBits int, target time.Duration) (benchResult, error) {
+	rate, err := a.hashRate(ctx, duration)
+	if err != nil {
+		return benchResult{}, err
+	}
+
+	result := benchResult{
+		Algorithm:         a.name,
+		AttemptsPerSecond: rate,
+	}
+
+	if target > 0 {
+		if result.RecommendedBits, err = a.bitsForSolveTime(rate, target); err != nil {
+			return benchResult{}, err
+		}
+	}
+
+	for bits := minBits; bits <= maxBits && bits <= a.maxBits; bits++ {
+		result.Levels = append(result.Levels, benchLevel{
+			Bits:     bits,
+			Attempts: a.expectedAttempts(bits),
+			Median:   a.quantileAttempts(bits, 0.5) / rate,
+			Mean:     a.expectedAttempts(bits) / rate,
+			P90:      a.quantileAttempts(bits, 0.9) / rate,
+			P99:      a.quantileAttempts(bits, 0.99) / rate,
+		})
+	}
+
+	return result, nil
+}
+
+func writeBench(w io.Writer, results []benchResult, target time.Duration) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for i, r := range results {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+
+		fmt.Fprintf(tw, "%s: %.0f attempts/s\n", r.Algorithm, r.AttemptsPerSecond)
+		if r.RecommendedBits > 0 {
+			fmt.Fprintf(tw, "recommended bits for %s median: %d\n", target, r.RecommendedBits)
+		}
+
+		fmt.Fprintf(tw, "\nbits\tattempts\tmedian\tmean\tp90\tp99\n")
+		for _, l := range r.Levels {
+			fmt.Fprintf(tw, "%d\t%.0f\t%s\t%s\t%s\t%s\n",
+				l.Bits, l.Attempts, formatSeconds(l.Median), formatSeconds(l.Mean), formatSeconds(l.P90), formatSeconds(l.P99))
+		}
+	}
+
+	return tw.Flush()
+}
+
+// formatSeconds - returns rounded duration, in years if it's longer than year
+func formatSeconds(seconds float64) string {
+	const year = 365 * 24 * time.Hour
+
+	if seconds >= year.Seconds() {
+		return fmt.Sprintf("%.1fy", seconds/year.Seconds())
+	}
+
+	d := time.Duration(seconds * float64(time.Second))
+	switch {
+	case d >= time.Minute:
+		d = d.Round(time.Second)
+	case d >= time.Second:
+		d = d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		d = d.Round(10 * time.Microsecond)
+	default:
+		d = d.Round(10 * time.Nanosecond)
+	}
+
+	return d.String()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/stamp"
+)
+
+// mintStamp - mint email stamp compatible with reference hashcash tool
+func mintStamp(ctx context.Context, args []string) error {
+	var (
+		bits        int
+		resource    string
+		maxAttempts int
+		header      bool
+	)
+
+	flags := flag.NewFlagSet("stamp", flag.ExitOnError)
+	flags.IntVar(&bits, "bits", 20, "number of zero bits of sha1 hash")
+	flags.StringVar(&resource, "resource", "", "recipient email address")
+	flags.IntVar(&maxAttempts, "max-attempts", 0, "max attempts to mint stamp, 0 - unlimited")
+	flags.BoolVar(&header, "header", false, "print stamp as X-Hashcash header line")
+	flags.Parse(args)
+
+	if resource == "" {
+		return errResourceRequired
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = math.MaxInt
+	}
+
+	s, err := stamp.Mint(ctx, resource, bits, maxAttempts)
+	if err != nil {
+		return err
+	}
+
+	if header {
+		fmt.Println(s.Header())
+	} else {
+		fmt.Println(s)
+	}
+	return nil
+}
+
+// checkStamp - check email stamp like reference hashcash tool,
+// stamp is rejected if it's found in double spend database
+func checkStamp(ctx context.Context, args []string) error {
+	var (
+		opts     stamp.Opts
+		resource string
+		dbPath   string
+	)
+
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.IntVar(&opts.Bits, "bits", 20, "min number of zero bits of sha1 hash")
+	flags.StringVar(&resource, "resource", "", "recipient email address")
+	flags.DurationVar(&opts.Expiry, "expiry", 28*24*time.Hour, "stamp validity period, 0 - stamps never expire")
+	flags.DurationVar(&opts.Grace, "grace", 48*time.Hour, "tolerance of clock difference with sender")
+	flags.StringVar(&dbPath, "db", "", "double spend database file, empty - don't check double spending")
+	flags.Parse(args)
+
+	if resource == "" {
+		return errResourceRequired
+	}
+
+	line, err := readHeader(flags)
+	if err != nil {
+		return err
+	}
+	if value, ok := stamp.FromHeader(line); ok {
+		line = value
+	}
+
+	if dbPath != "" {
+		db, err := stamp.OpenDB(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		opts.SpentDB = db
+	}
+
+	checker, err := stamp.NewChecker(opts)
+	if err != nil {
+		return err
+	}
+	if err := checker.Check(line, resource); err != nil {
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
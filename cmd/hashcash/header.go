@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+)
+
+var errHeaderRequired = errors.New("header is required as argument or on stdin")
+
+// readHeader - returns header passed as first argument or first line of stdin,
+// so commands can be piped
+func readHeader(flags *flag.FlagSet) (string, error) {
+	if flags.NArg() > 0 {
+		return strings.TrimSpace(flags.Arg(0)), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	header := strings.TrimSpace(line)
+	if header == "" {
+		return "", errHeaderRequired
+	}
+
+	return header, nil
+}
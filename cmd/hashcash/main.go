@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// command - subcommand of hashcash tool
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{
+		name:        "bench",
+		description: "measure hash rate and expected solve times of each algorithm",
+		run:         bench,
+	},
+	{
+		name:        "mint",
+		description: "create puzzle for resource",
+		run:         mint,
+	},
+	{
+		name:        "solve",
+		description: "solve puzzle header",
+		run:         solve,
+	},
+	{
+		name:        "verify",
+		description: "check solved header bits, hash, expiration and resource",
+		run:         verify,
+	},
+	{
+		name:        "parse",
+		description: "print header fields as json",
+		run:         parse,
+	},
+	{
+		name:        "stamp",
+		description: "mint email stamp compatible with reference hashcash tool",
+		run:         mintStamp,
+	},
+	{
+		name:        "check",
+		description: "check email stamp and reject double spent ones",
+		run:         checkStamp,
+	},
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	c, ok := findCommand(os.Args[1])
+	if !ok {
+		fmt.Printf("unknown command: %s\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err := c.run(ctx, os.Args[2:]); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// findCommand - returns command by name
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func usage() {
+	fmt.Println("Usage: hashcash <command> [flags]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, c := range commands {
+		fmt.Printf("  %-8s %s\n", c.name, c.description)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+)
+
+var errResourceRequired = errors.New("resource is required")
+
+// mint - create puzzle for resource and print its header
+func mint(ctx context.Context, args []string) error {
+	var (
+		bits     int
+		attempts float64
+		resource string
+	)
+
+	flags := flag.NewFlagSet("mint", flag.ExitOnError)
+	flags.IntVar(&bits, "bits", 5, "number of zero bits")
+	flags.Float64Var(&attempts, "attempts", 0, "difficulty in expected attempts, overrides bits if set")
+	flags.StringVar(&resource, "resource", "", "resource the puzzle is bound to, e.g. client address")
+	flags.Parse(args)
+
+	if resource == "" {
+		return errResourceRequired
+	}
+
+	var (
+		h   *hashcash.Hashcash
+		err error
+	)
+	if attempts > 0 {
+		h, err = hashcash.NewTarget(attempts, resource)
+	} else {
+		h, err = hashcash.New(bits, resource)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(h.Header())
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+)
+
+// parsedHeader - header fields
+// Attempts - expected attempts to solve header, ZeroBits - actual number of zero bits of hash,
+// Error - why header can't be checked if it isn't solved
+type parsedHeader struct {
+	Bits      int       `json:"bits"`
+	Attempts  float64   `json:"attempts"`
+	Date      time.Time `json:"date"`
+	Resource  string    `json:"resource"`
+	Extension string    `json:"extension"`
+	Rand      string    `json:"rand"`
+	Counter   int       `json:"counter"`
+	Key       string    `json:"key"`
+	Hash      string    `json:"hash"`
+	ZeroBits  int       `json:"zero_bits"`
+	Solved    bool      `json:"solved"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// parse - print header fields as json
+func parse(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("parse", flag.ExitOnError)
+	flags.Parse(args)
+
+	header, err := readHeader(flags)
+	if err != nil {
+		return err
+	}
+
+	h, err := hashcash.ParseHeader(header)
+	if err != nil {
+		return err
+	}
+
+	hash, err := h.Header().Hash()
+	if err != nil {
+		return err
+	}
+	zeroBits, err := h.Header().ZeroBits()
+	if err != nil {
+		return err
+	}
+	parsed := parsedHeader{
+		Bits:      h.Bits(),
+		Attempts:  h.Difficulty(),
+		Date:      h.Date(),
+		Resource:  h.Resource(),
+		Extension: h.Extension(),
+		Rand:      h.Rand(),
+		Counter:   h.Counter(),
+		Key:       h.Key(),
+		Hash:      hash,
+		ZeroBits:  zeroBits,
+	}
+	if parsed.Solved, err = h.IsSolved(); err != nil {
+		parsed.Error = err.Error()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(parsed)
+}
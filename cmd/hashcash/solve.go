@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+)
+
+// solve - compute counter of header and print solved header,
+// attempts and elapsed time are printed to stderr
+func solve(ctx context.Context, args []string) error {
+	var (
+		maxAttempts int
+		timeout     time.Duration
+	)
+
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	flags.IntVar(&maxAttempts, "max-attempts", 100000000, "max attempts to compute hash")
+	flags.DurationVar(&timeout, "timeout", 0, "max solving duration, 0 - unlimited")
+	flags.Parse(args)
+
+	header, err := readHeader(flags)
+	if err != nil {
+		return err
+	}
+
+	h, err := hashcash.ParseHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	if err := h.ComputeContext(ctx, maxAttempts); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "solved in %d attempts, %s\n", h.Counter()+1, time.Since(start).Round(time.Millisecond))
+	fmt.Println(h.Header())
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+)
+
+// Errors of header verification
+var (
+	errNotEnoughBits    = errors.New("header has less zero bits than required")
+	errNotSolved        = errors.New("header hash doesn't have enough zero bits")
+	errResourceMismatch = errors.New("header resource doesn't match")
+	errExpired          = errors.New("header expired")
+)
+
+// verify - check solved header like server does, except that it was issued by server
+func verify(ctx context.Context, args []string) error {
+	var (
+		bits     int
+		resource string
+		ttl      time.Duration
+	)
+
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	flags.IntVar(&bits, "bits", 0, "min number of zero bits, 0 - any")
+	flags.StringVar(&resource, "resource", "", "expected resource, empty - any")
+	flags.DurationVar(&ttl, "ttl", time.Minute, "puzzle ttl, 0 - don't check expiration")
+	flags.Parse(args)
+
+	header, err := readHeader(flags)
+	if err != nil {
+		return err
+	}
+
+	h, err := hashcash.ParseHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if h.Bits() < bits {
+		return fmt.Errorf("%w: %d < %d", errNotEnoughBits, h.Bits(), bits)
+	}
+	if resource != "" && !h.EqualResource(resource) {
+		return fmt.Errorf("%w: %q", errResourceMismatch, h.Resource())
+	}
+	if ttl > 0 && !h.IsActual(ttl) {
+		return fmt.Errorf("%w: created at %s", errExpired, h.Date().Format(time.RFC3339))
+	}
+
+	ok, err := h.IsSolved()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNotSolved
+	}
+
+	fmt.Println("ok")
+	return nil
+}
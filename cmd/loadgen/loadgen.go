@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/app/client"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/histogram"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Phases of client session, measured by spans of client service
+const (
+	phasePuzzle = "puzzle"
+	phaseSolve  = "solve"
+	phaseRedeem = "redeem"
+	phaseTotal  = "total"
+)
+
+var phaseSpans = map[string]string{
+	"service.Client.requestPuzzle":  phasePuzzle,
+	"service.Client.solvePuzzle":    phaseSolve,
+	"service.Client.redeemSolution": phaseRedeem,
+}
+
+// loadOpts - load options
+// Rate - sessions started per second, sessions are started as fast as concurrency allows if rate <= 0
+type loadOpts struct {
+	Rate        float64
+	Concurrency int
+	Duration    time.Duration
+}
+
+// loadgen - runs concurrent client sessions and collects their results
+type loadgen struct {
+	opts   loadOpts
+	client client.Opts
+
+	phases  map[string]*histogram.Histogram
+	errsMu  sync.Mutex
+	errs    map[string]int
+	ok      atomic.Int64
+	skipped atomic.Int64
+}
+
+func newLoadgen(opts loadOpts) *loadgen {
+	l := &loadgen{
+		opts:   opts,
+		phases: make(map[string]*histogram.Histogram),
+		errs:   make(map[string]int),
+	}
+	for _, phase := range []string{phasePuzzle, phaseSolve, phaseRedeem, phaseTotal} {
+		l.phases[phase] = histogram.New(nil)
+	}
+
+	return l
+}
+
+// run - start sessions for duration and wait for started ones to finish
+// Context cancellation interrupts running sessions
+func (l *loadgen) run(ctx context.Context) time.Duration {
+	scheduleCtx, cancel := context.WithTimeout(ctx, l.opts.Duration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, l.opts.Concurrency)
+
+	start := func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			l.session(ctx)
+		}()
+	}
+
+	began := time.Now()
+
+	if l.opts.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / l.opts.Rate))
+		defer ticker.Stop()
+
+	rate:
+		for {
+			select {
+			case <-scheduleCtx.Done():
+				break rate
+			case <-ticker.C:
+				select {
+				case slots <- struct{}{}:
+					start()
+				default:
+					l.skipped.Add(1)
+				}
+			}
+		}
+	} else {
+	unlimited:
+		for {
+			select {
+			case <-scheduleCtx.Done():
+				break unlimited
+			case slots <- struct{}{}:
+				start()
+			}
+		}
+	}
+
+	wg.Wait()
+
+	return time.Since(began)
+}
+
+// session - connect to server once and record result
+// Latencies are recorded for successful sessions and phases only
+func (l *loadgen) session(ctx context.Context) {
+	start := time.Now()
+	_, err := client.Connect(ctx, l.client)
+
+	if err == nil {
+		l.phases[phaseTotal].Record(time.Since(start))
+		l.ok.Add(1)
+		return
+	}
+
+	l.errsMu.Lock()
+	l.errs[errorReason(err)]++
+	l.errsMu.Unlock()
+}
+
+// errorReason - returns error text without connection addresses to group errors
+func errorReason(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op + ": " + opErr.Err.Error()
+	}
+
+	return err.Error()
+}
+
+// OnStart - implements sdktrace.SpanProcessor
+func (l *loadgen) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {}
+
+// OnEnd - record duration of session phase span
+func (l *loadgen) OnEnd(s sdktrace.ReadOnlySpan) {
+	if phase, ok := phaseSpans[s.Name()]; ok && s.Status().Code != codes.Error {
+		l.phases[phase].Record(s.EndTime().Sub(s.StartTime()))
+	}
+}
+
+// Shutdown - implements sdktrace.SpanProcessor
+func (l *loadgen) Shutdown(ctx context.Context) error { return nil }
+
+// ForceFlush - implements sdktrace.SpanProcessor
+func (l *loadgen) ForceFlush(ctx context.Context) error { return nil }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/app/client"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/certs"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/config"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/log"
+	"github.com/pvarentsov/powtcp/internal/pkg/service"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// logLevelOff - level above error, client sessions log nothing with it
+const logLevelOff = int(log.LevelError) + 4
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var (
+		opts     loadOpts
+		jsonOut  bool
+		logLevel int
+	)
+	flag.Float64Var(&opts.Rate, "rate", 0, "sessions started per second, 0 - as fast as concurrency allows")
+	flag.IntVar(&opts.Concurrency, "concurrency", 10, "max concurrent sessions")
+	flag.DurationVar(&opts.Duration, "duration", 10*time.Second, "duration of starting new sessions")
+	flag.BoolVar(&jsonOut, "json", false, "print report in json")
+	flag.IntVar(&logLevel, "log-level", logLevelOff, "log level of client sessions, off by default")
+
+	// Client settings are taken from client config
+	config, err := config.Parse("config")
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if opts.Concurrency <= 0 {
+		fmt.Println("concurrency must be more than zero")
+		os.Exit(1)
+	}
+
+	logger := log.New(log.Opts{
+		Level: log.Level(logLevel),
+		Json:  config.Client.LogJson,
+	})
+
+	var tlsConfig *tls.Config
+	if config.Client.TLSEnabled {
+		tlsConfig, err = certs.NewClientConfig(ctx, certs.ClientOpts{
+			CAFile:     config.Client.TLSCAFile,
+			CertFile:   config.Client.TLSCertFile,
+			KeyFile:    config.Client.TLSKeyFile,
+			ServerName: config.Client.TLSServerName,
+			Logger:     logger,
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	load := newLoadgen(opts)
+
+	// Phase latencies are taken from spans of client service
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(load))
+	defer tracerProvider.Shutdown(context.Background())
+
+	load.client = client.Opts{
+		Config: newConfigClient(config),
+		Logger: logger,
+		Service: service.NewClient(service.ClientOpts{
+			Config: newConfigService(config),
+			Logger: logger,
+			Tracer: tracerProvider.Tracer("github.com/pvarentsov/powtcp/service"),
+		}),
+		TLSConfig: tlsConfig,
+	}
+
+	elapsed := load.run(ctx)
+	report := newReport(load, elapsed)
+
+	if jsonOut {
+		err = report.writeJSON(os.Stdout)
+	} else {
+		err = report.writeText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
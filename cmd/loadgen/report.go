@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/histogram"
+)
+
+// report - load results, durations are in milliseconds
+type report struct {
+	DurationMs float64                `json:"duration_ms"`
+	Sessions   int64                  `json:"sessions"`
+	Succeeded  int64                  `json:"succeeded"`
+	Failed     int64                  `json:"failed"`
+	Skipped    int64                  `json:"skipped"`
+	Throughput float64                `json:"throughput"`
+	Errors     map[string]int         `json:"errors"`
+	Phases     map[string]phaseReport `json:"phases"`
+	phaseOrder []string
+}
+
+// phaseReport - latency summary of session phase
+type phaseReport struct {
+	Count   int            `json:"count"`
+	MinMs   float64        `json:"min_ms"`
+	MeanMs  float64        `json:"mean_ms"`
+	P50Ms   float64        `json:"p50_ms"`
+	P90Ms   float64        `json:"p90_ms"`
+	P99Ms   float64        `json:"p99_ms"`
+	MaxMs   float64        `json:"max_ms"`
+	Buckets []bucketReport `json:"buckets"`
+}
+
+// bucketReport - histogram bucket, le is null for overflow bucket
+type bucketReport struct {
+	LeMs  *float64 `json:"le_ms"`
+	Count int      `json:"count"`
+}
+
+// newReport - collect results of finished load
+func newReport(l *loadgen, elapsed time.Duration) report {
+	r := report{
+		DurationMs: histogram.Milliseconds(elapsed),
+		Succeeded:  l.ok.Load(),
+		Skipped:    l.skipped.Load(),
+		Errors:     make(map[string]int),
+		Phases:     make(map[string]phaseReport),
+		phaseOrder: []string{phasePuzzle, phaseSolve, phaseRedeem, phaseTotal},
+	}
+
+	for reason, count := range l.errs {
+		r.Errors[reason] = count
+		r.Failed += int64(count)
+	}
+	r.Sessions = r.Succeeded + r.Failed
+	r.Throughput = float64(r.Succeeded) / elapsed.Seconds()
+
+	for _, phase := range r.phaseOrder {
+		r.Phases[phase] = newPhaseReport(l.phases[phase].Snapshot())
+	}
+
+	return r
+}
+
+func newPhaseReport(s histogram.Snapshot) phaseReport {
+	p := phaseReport{
+		Count:  s.Count,
+		MinMs:  histogram.Milliseconds(s.Min),
+		MeanMs: histogram.Milliseconds(s.Mean),
+		P50Ms:  histogram.Milliseconds(s.P50),
+		P90Ms:  histogram.Milliseconds(s.P90),
+		P99Ms:  histogram.Milliseconds(s.P99),
+		MaxMs:  histogram.Milliseconds(s.Max),
+	}
+
+	for _, b := range s.Buckets {
+		bucket := bucketReport{Count: b.Count}
+		if b.UpperBound != math.MaxInt64 {
+			le := histogram.Milliseconds(b.UpperBound)
+			bucket.LeMs = &le
+		}
+		p.Buckets = append(p.Buckets, bucket)
+	}
+
+	return p
+}
+
+// writeJSON - write report as indented json
+func (r report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// writeText - write report as human readable tables
+func (r report) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "duration\t%s\n", time.Duration(r.DurationMs*float64(time.Millisecond)).Round(time.Millisecond))
+	fmt.Fprintf(tw, "sessions\t%d\n", r.Sessions)
+	fmt.Fprintf(tw, "succeeded\t%d\n", r.Succeeded)
+	fmt.Fprintf(tw, "failed\t%d\n", r.Failed)
+	fmt.Fprintf(tw, "skipped\t%d\n", r.Skipped)
+	fmt.Fprintf(tw, "throughput\t%.2f/s\n", r.Throughput)
+
+	if len(r.Errors) > 0 {
+		reasons := make([]string, 0, len(r.Errors))
+		for reason := range r.Errors {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool { return r.Errors[reasons[i]] > r.Errors[reasons[j]] })
+
+		fmt.Fprintf(tw, "\nerror\tcount\n")
+		for _, reason := range reasons {
+			fmt.Fprintf(tw, "%s\t%d\n", reason, r.Errors[reason])
+		}
+	}
+
+	fmt.Fprintf(tw, "\nphase\tcount\tmin\tmean\tp50\tp90\tp99\tmax\n")
+	for _, phase := range r.phaseOrder {
+		p := r.Phases[phase]
+		fmt.Fprintf(tw, "%s\t%d\t%.2fms\t%.2fms\t%.2fms\t%.2fms\t%.2fms\t%.2fms\n",
+			phase, p.Count, p.MinMs, p.MeanMs, p.P50Ms, p.P90Ms, p.P99Ms, p.MaxMs)
+	}
+
+	for _, phase := range r.phaseOrder {
+		p := r.Phases[phase]
+		if p.Count == 0 {
+			continue
+		}
+
+		fmt.Fprintf(tw, "\n%s latency\tcount\n", phase)
+		for _, b := range p.Buckets {
+			if b.Count == 0 {
+				continue
+			}
+			if b.LeMs == nil {
+				fmt.Fprintf(tw, "> %s\t%d\n", lastBound(p.Buckets), b.Count)
+				continue
+			}
+			fmt.Fprintf(tw, "<= %gms\t%d\n", *b.LeMs, b.Count)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// lastBound - returns upper bound of the last finite bucket
+func lastBound(buckets []bucketReport) string {
+	for i := len(buckets) - 1; i >= 0; i-- {
+		if buckets[i].LeMs != nil {
+			return fmt.Sprintf("%gms", *buckets[i].LeMs)
+		}
+	}
+	return "0ms"
+}
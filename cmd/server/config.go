@@ -3,6 +3,7 @@ package main
 import (
 	"time"
 
+	"github.com/pvarentsov/powtcp/internal/app/server"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/config"
 )
 
@@ -20,6 +21,25 @@ func (cc *configServer) Address() string {
 	return cc.c.Server.Address
 }
 
+func (cc *configServer) Listeners() []server.ListenerConfig {
+	listeners := make([]server.ListenerConfig, 0, len(cc.c.Server.Listeners))
+	for _, l := range cc.c.Server.Listeners {
+		network := l.Network
+		if network == "" {
+			network = "tcp"
+		}
+
+		listeners = append(listeners, server.ListenerConfig{
+			Network:           network,
+			Address:           l.Address,
+			Path:              l.Path,
+			ConnectionTimeout: time.Duration(l.ConnectionTimeout) * time.Millisecond,
+			PuzzleZeroBits:    l.Bits,
+		})
+	}
+	return listeners
+}
+
 func (cc *configServer) ShutdownTimeout() time.Duration {
 	return time.Duration(cc.c.Server.ShutdownTimeout) * time.Millisecond
 }
@@ -28,6 +48,34 @@ func (cc *configServer) ConnectionTimeout() time.Duration {
 	return time.Duration(cc.c.Server.ConnectionTimeout) * time.Millisecond
 }
 
+func (cc *configServer) MaxConnections() int {
+	return cc.c.Server.MaxConnections
+}
+
+func (cc *configServer) MaxConnectionsPerIP() int {
+	return cc.c.Server.MaxConnectionsPerIP
+}
+
+func (cc *configServer) ConnectionQueueSize() int {
+	return cc.c.Server.ConnectionQueueSize
+}
+
+func (cc *configServer) ConnectionQueueTimeout() time.Duration {
+	return time.Duration(cc.c.Server.ConnectionQueueTimeout) * time.Millisecond
+}
+
+func (cc *configServer) ProxyProtocol() bool {
+	return cc.c.Server.ProxyProtocol
+}
+
+func (cc *configServer) TrustedProxyCIDRs() []string {
+	return cc.c.Server.TrustedProxyCIDRs
+}
+
+func (cc *configServer) BackendAddress() string {
+	return cc.c.Server.BackendAddress
+}
+
 func newConfigService(c *config.Config) *configService {
 	return &configService{
 		c: c,
@@ -45,3 +93,11 @@ func (cs *configService) PuzzleTTL() time.Duration {
 func (cs *configService) PuzzleZeroBits() int {
 	return cs.c.Hashcash.Bits
 }
+
+func (cs *configService) PuzzleAttempts() float64 {
+	return cs.c.Hashcash.Attempts
+}
+
+func (cs *configService) TracePropagation() bool {
+	return cs.c.Trace.Propagate
+}
@@ -2,23 +2,38 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/pvarentsov/powtcp/internal/app/server"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/binding"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/cache"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/certs"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/config"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/ipfilter"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/log"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/quote"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/tcp"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/trace"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/trust"
 	"github.com/pvarentsov/powtcp/internal/pkg/service"
 )
 
+// hashRateDuration - duration of hash rate measuring if target solve time is set
+const hashRateDuration = time.Second
+
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	config, err := config.Parse("config")
+	configPath := config.Path("config")
+
+	config, err := config.ParseFromPath(configPath)
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
@@ -32,6 +47,28 @@ func main() {
 		Json:  config.Server.LogJson,
 	})
 
+	if err := deriveZeroBits(ctx, config, logger); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if attempts := config.Hashcash.Attempts; attempts != 0 {
+		if err := hashcash.ValidateAttempts(attempts); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	tracerProvider, err := trace.New(ctx, trace.Opts{
+		ServiceName: "powtcp-server",
+		Exporter:    trace.Exporter(config.Trace.Exporter),
+		Endpoint:    config.Trace.Endpoint,
+		Insecure:    config.Trace.Insecure,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
 	puzzleCache := cache.New[string, struct{}](ctx, cache.Opts{
 		CleanInterval: configService.PuzzleTTL(),
 		Logger:        logger,
@@ -40,22 +77,83 @@ func main() {
 	resourceCache := cache.New[int, string](ctx, cache.Opts{
 		Logger: logger,
 	})
-	for i, r := range resources {
+	for i, r := range quote.Quotes {
 		resourceCache.Add(i, r)
 	}
 
+	trustPolicy, err := trust.New(trust.Opts{
+		CIDRs:     config.Server.TrustedCIDRs,
+		APIKeys:   config.Server.TrustedAPIKeys,
+		CertNames: config.Server.TrustedCertNames,
+		ZeroBits:  config.Server.TrustedZeroBits,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	ipFilter, err := ipfilter.New(ctx, ipfilter.Opts{
+		Allow:        config.Server.AllowCIDRs,
+		Deny:         config.Server.DenyCIDRs,
+		BanThreshold: config.Server.BanThreshold,
+		BanWindow:    time.Duration(config.Server.BanWindow) * time.Millisecond,
+		BanDuration:  time.Duration(config.Server.BanDuration) * time.Millisecond,
+		Logger:       logger,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	resourceBinder, err := binding.New(binding.Opts{
+		Policy:   binding.Policy(config.Server.PuzzleBinding),
+		PrefixV4: config.Server.PuzzleBindingPrefixV4,
+		PrefixV6: config.Server.PuzzleBindingPrefixV6,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	var resourceHandler service.ResourceHandler
+	if configServer.BackendAddress() != "" {
+		resourceHandler = proxyResourceHandler{}
+	}
+
 	service := service.NewServer(service.ServerOpts{
-		Config:        configService,
-		Logger:        logger,
-		PuzzleCache:   puzzleCache,
-		ResourceCache: resourceCache,
-		ErrorChecker:  tcp.NewConnErrorChecker(),
+		Config:          configService,
+		Logger:          logger,
+		PuzzleCache:     puzzleCache,
+		ResourceCache:   resourceCache,
+		ErrorChecker:    tcp.NewConnErrorChecker(),
+		PuzzlePolicy:    trustPolicy,
+		FailureReporter: ipFilter,
+		ResourceBinder:  resourceBinder,
+		ResourceHandler: resourceHandler,
+		Tracer:          tracerProvider.Tracer("github.com/pvarentsov/powtcp/service"),
 	})
 
+	var tlsConfig *tls.Config
+	if config.Server.TLSEnabled {
+		tlsConfig, err = certs.NewServerConfig(ctx, certs.ServerOpts{
+			CertFile:       config.Server.TLSCertFile,
+			KeyFile:        config.Server.TLSKeyFile,
+			ClientCAFile:   config.Server.TLSClientCAFile,
+			ReloadInterval: time.Duration(config.Server.TLSReloadInterval) * time.Millisecond,
+			Logger:         logger,
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
 	server, err := server.Listen(ctx, server.Opts{
-		Config:  configServer,
-		Logger:  logger,
-		Service: service,
+		Config:    configServer,
+		Logger:    logger,
+		Service:   service,
+		TLSConfig: tlsConfig,
+		IPFilter:  ipFilter,
 	})
 	if err != nil {
 		fmt.Println(err.Error())
@@ -63,17 +161,109 @@ func main() {
 	}
 
 	logger.Debug("server started",
-		"address", configServer.Address(),
+		"listeners", server.Addrs(),
 		"shutdown_timeout", configServer.ShutdownTimeout(),
 		"connection_timeout", configServer.ConnectionTimeout(),
+		"max_connections", configServer.MaxConnections(),
+		"max_connections_per_ip", configServer.MaxConnectionsPerIP(),
+		"connection_queue_size", configServer.ConnectionQueueSize(),
+		"connection_queue_timeout", configServer.ConnectionQueueTimeout(),
+		"tls_enabled", config.Server.TLSEnabled,
+		"tls_mutual", config.Server.TLSClientCAFile != "",
+		"trusted_cidrs", config.Server.TrustedCIDRs,
+		"trusted_cert_names", config.Server.TrustedCertNames,
+		"trusted_zero_bits", config.Server.TrustedZeroBits,
+		"allow_cidrs", config.Server.AllowCIDRs,
+		"deny_cidrs", config.Server.DenyCIDRs,
+		"ban_threshold", config.Server.BanThreshold,
+		"proxy_protocol", configServer.ProxyProtocol(),
+		"trusted_proxy_cidrs", configServer.TrustedProxyCIDRs(),
+		"backend_address", configServer.BackendAddress(),
 		"puzzle_ttl", configService.PuzzleTTL(),
 		"puzzle_zero_bits", configService.PuzzleZeroBits(),
+		"puzzle_attempts", configService.PuzzleAttempts(),
+		"puzzle_binding", resourceBinder.Policy(),
+		"trace_exporter", config.Trace.Exporter,
+		"trace_propagation", configService.TracePropagation(),
 	)
 
 	signalChannel := make(chan os.Signal, 1)
-	signal.Notify(signalChannel, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(signalChannel, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	for sig := range signalChannel {
+		if sig == syscall.SIGHUP {
+			reloadIPFilter(configPath, ipFilter, logger)
+			continue
+		}
+		break
+	}
 
-	<-signalChannel
-	cancel()
 	server.Shutdown()
+	cancel()
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), time.Second)
+	defer flushCancel()
+
+	if err := tracerProvider.Shutdown(flushCtx); err != nil {
+		logger.Error(err.Error(), "op", "main")
+	}
+}
+
+// deriveZeroBits - set hashcash bits by target median solve time if it's configured,
+// hash rate is measured on server if it isn't configured
+func deriveZeroBits(ctx context.Context, c *config.Config, logger *slog.Logger) error {
+	target := time.Duration(c.Hashcash.TargetSolveTime) * time.Millisecond
+	if target <= 0 {
+		return nil
+	}
+
+	rate := c.Hashcash.HashRate
+	if rate <= 0 {
+		measured, err := hashcash.HashRate(ctx, hashRateDuration)
+		if err != nil {
+			return err
+		}
+		rate = measured
+	}
+
+	bits, err := hashcash.BitsForSolveTime(rate, target)
+	if err != nil {
+		return err
+	}
+	c.Hashcash.Bits = bits
+
+	logger.Info("puzzle zero bits derived",
+		"bits", bits,
+		"target_solve_time", target,
+		"median_solve_time", time.Duration(hashcash.QuantileAttempts(bits, 0.5)/rate*float64(time.Second)),
+		"attempts_per_second", int(rate),
+	)
+
+	return nil
+}
+
+// reloadIPFilter - reload allow and deny lists from config
+func reloadIPFilter(configPath string, ipFilter *ipfilter.Filter, logger *slog.Logger) {
+	const op = "main.reloadIPFilter"
+
+	c, err := config.ParseFromPath(configPath)
+	if err != nil {
+		logger.Error(err.Error(), "op", op)
+		return
+	}
+
+	if err := ipFilter.Update(c.Server.AllowCIDRs, c.Server.DenyCIDRs); err != nil {
+		logger.Error(err.Error(), "op", op)
+		return
+	}
+
+	logger.Info("ip filter reloaded", "allow_cidrs", c.Server.AllowCIDRs, "deny_cidrs", c.Server.DenyCIDRs)
+}
+
+// proxyResourceHandler - sends empty resource in proxy mode,
+// client connection is spliced to backend after it
+type proxyResourceHandler struct{}
+
+func (proxyResourceHandler) HandleResource(ctx context.Context, clientID string) (string, error) {
+	return "", nil
 }
@@ -0,0 +1,95 @@
+// Glue for hashcash solver compiled to WebAssembly.
+// Requires wasm_exec.js from Go distribution to be loaded first.
+//
+//   const solver = await PowtcpHashcash.load("hashcash.wasm");
+//   const controller = new AbortController();
+//   const solution = await solver.solve(puzzle, {
+//     onProgress: (attempts) => console.log(attempts),
+//     signal: controller.signal,
+//   });
+(function (root) {
+  "use strict";
+
+  const defaultMaxAttempts = 100000000;
+
+  // load - instantiate solver from wasm url, response or bytes
+  async function load(source) {
+    const go = new root.Go();
+
+    let result;
+    if (typeof source === "string" || source instanceof URL) {
+      source = fetch(source);
+    }
+    if (source instanceof Promise || (typeof Response !== "undefined" && source instanceof Response)) {
+      result = await WebAssembly.instantiateStreaming(source, go.importObject);
+    } else {
+      result = await WebAssembly.instantiate(source, go.importObject);
+    }
+
+    go.run(result.instance);
+
+    return { solve };
+  }
+
+  // solve - solve puzzle header, resolves with solved header
+  // Rejects with AbortError if options.signal is aborted
+  function solve(header, options = {}) {
+    const { onProgress, signal, maxAttempts = defaultMaxAttempts } = options;
+
+    return new Promise((resolve, reject) => {
+      if (signal && signal.aborted) {
+        reject(abortError());
+        return;
+      }
+
+      // Solver may report progress before it returns cancel function,
+      // so progress callback also stops it if signal is aborted
+      let cancel;
+      const onAbort = () => cancel && cancel();
+      const progress = (attempts) => {
+        if (onProgress) {
+          onProgress(attempts);
+        }
+        return Boolean(signal && signal.aborted);
+      };
+
+      if (signal) {
+        signal.addEventListener("abort", onAbort);
+      }
+
+      const result = root.powtcpHashcashSolve(header, maxAttempts, progress, (err, solution) => {
+        if (signal) {
+          signal.removeEventListener("abort", onAbort);
+        }
+        if (signal && signal.aborted) {
+          reject(abortError());
+        } else if (err) {
+          reject(new Error(err));
+        } else {
+          resolve(solution);
+        }
+      });
+
+      // Solver returns Error if arguments are incorrect
+      if (result instanceof Error) {
+        if (signal) {
+          signal.removeEventListener("abort", onAbort);
+        }
+        reject(result);
+        return;
+      }
+      cancel = result;
+    });
+  }
+
+  function abortError() {
+    return new DOMException("solving aborted", "AbortError");
+  }
+
+  const api = { load };
+  if (typeof module === "object" && module.exports) {
+    module.exports = api;
+  } else {
+    root.PowtcpHashcash = api;
+  }
+})(typeof globalThis !== "undefined" ? globalThis : this);
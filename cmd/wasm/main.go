@@ -0,0 +1,74 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"context"
+	"syscall/js"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+)
+
+// yieldInterval - pause between progress reports to let js event loop run,
+// so page stays responsive and solving can be canceled
+const yieldInterval = time.Millisecond
+
+func main() {
+	js.Global().Set("powtcpHashcashSolve", js.FuncOf(solve))
+	select {}
+}
+
+// solve - solve puzzle in background
+// Arguments: header, maxAttempts, onProgress(attempts), onDone(error, header).
+// Solving stops if onProgress returns true or returned cancel function is called
+// Returns Error instead of cancel function if arguments are incorrect,
+// panic would stop go runtime and every next call would fail
+func solve(this js.Value, args []js.Value) any {
+	if len(args) < 4 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeNumber || args[3].Type() != js.TypeFunction {
+		return js.Global().Get("Error").New("powtcpHashcashSolve: header, maxAttempts, onProgress and onDone are required")
+	}
+
+	header := args[0].String()
+	maxAttempts := args[1].Int()
+	onProgress := args[2]
+	onDone := args[3]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelFunc := js.FuncOf(func(this js.Value, args []js.Value) any {
+		cancel()
+		return nil
+	})
+
+	go func() {
+		defer cancelFunc.Release()
+		defer cancel()
+
+		solution, err := compute(ctx, header, maxAttempts, func(attempts int) {
+			if onProgress.Type() == js.TypeFunction && onProgress.Invoke(attempts).Truthy() {
+				cancel()
+			}
+			time.Sleep(yieldInterval)
+		})
+		if err != nil {
+			onDone.Invoke(err.Error(), js.Null())
+			return
+		}
+
+		onDone.Invoke(js.Null(), solution)
+	}()
+
+	return cancelFunc
+}
+
+func compute(ctx context.Context, header string, maxAttempts int, progress func(attempts int)) (string, error) {
+	hashcash, err := hashcash.ParseHeader(header)
+	if err != nil {
+		return "", err
+	}
+	if err = hashcash.ComputeProgress(ctx, maxAttempts, progress); err != nil {
+		return "", err
+	}
+
+	return string(hashcash.Header()), nil
+}
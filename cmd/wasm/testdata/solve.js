@@ -0,0 +1,36 @@
+// Solve puzzle with wasm solver in node.
+// Usage: node solve.js wasm_exec.js hashcash.wasm header [abort_after_attempts] [invalid_call]
+// With invalid_call the solver is called without arguments before solving.
+"use strict";
+
+const fs = require("fs");
+const path = require("path");
+
+const [wasmExec, wasmFile, header, abortAfter, invalidCallFirst] = process.argv.slice(2);
+
+require(wasmExec);
+const PowtcpHashcash = require(path.join(__dirname, "..", "hashcash.js"));
+
+(async () => {
+  const solver = await PowtcpHashcash.load(fs.readFileSync(wasmFile));
+
+  // Incorrect call must not stop solver
+  const invalidCall = Boolean(invalidCallFirst) && globalThis.powtcpHashcashSolve() instanceof Error;
+  const controller = new AbortController();
+
+  let progress = 0;
+  const onProgress = (attempts) => {
+    progress = attempts;
+    if (abortAfter && attempts >= Number(abortAfter)) {
+      controller.abort();
+    }
+  };
+
+  try {
+    const solution = await solver.solve(header, { onProgress, signal: controller.signal });
+    console.log(JSON.stringify({ solution, progress, invalidCall }));
+  } catch (err) {
+    console.log(JSON.stringify({ error: err.name, progress, invalidCall }));
+  }
+  process.exit(0);
+})();
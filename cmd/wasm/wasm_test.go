@@ -0,0 +1,129 @@
+//go:build !js
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+	"github.com/stretchr/testify/require"
+)
+
+type solveResult struct {
+	Solution string `json:"solution"`
+	Error    string `json:"error"`
+	Progress int    `json:"progress"`
+
+	// InvalidCall - solver returned Error to call without arguments made before solving
+	InvalidCall bool `json:"invalidCall"`
+}
+
+func Test_WasmSolve(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+
+	wasmExec := findWasmExec(t)
+	wasmFile := buildWasm(t)
+
+	solve := func(t *testing.T, header string, abortAfter string, invalidCall bool) solveResult {
+		args := []string{"testdata/solve.js", wasmExec, wasmFile, header, abortAfter}
+		if invalidCall {
+			args = append(args, "invalid-call")
+		}
+
+		out, err := exec.Command(node, args...).Output()
+		require.NoError(t, err)
+
+		var result solveResult
+		require.NoError(t, json.Unmarshal(out, &result))
+		return result
+	}
+
+	t.Run("solve ok", func(t *testing.T) {
+		puzzle, err := hashcash.New(3, "127.0.0.1:56324")
+		require.NoError(t, err)
+
+		result := solve(t, string(puzzle.Header()), "", false)
+		require.Empty(t, result.Error)
+
+		solution, err := hashcash.ParseHeader(result.Solution)
+		require.NoError(t, err)
+		require.Equal(t, puzzle.Key(), solution.Key())
+
+		ok, err := solution.Header().IsHashCorrect(puzzle.Bits())
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("invalid call", func(t *testing.T) {
+		header := "1:3:20231102192537:resource::Cxphfw==:MA=="
+
+		result := solve(t, header, "", true)
+		require.True(t, result.InvalidCall)
+		require.Empty(t, result.Error)
+		require.NotEmpty(t, result.Solution)
+	})
+
+	t.Run("solve same as go", func(t *testing.T) {
+		header := "1:5:20231102192537:resource::Cxphfw==:MA=="
+
+		expected, err := hashcash.ParseHeader(header)
+		require.NoError(t, err)
+		require.NoError(t, expected.Compute(1000000))
+
+		result := solve(t, header, "", false)
+		require.Equal(t, string(expected.Header()), result.Solution)
+		require.Equal(t, 270000, result.Progress)
+	})
+
+	t.Run("solve aborted", func(t *testing.T) {
+		header := "1:20:20231102192537:resource::Cxphfw==:MA=="
+
+		result := solve(t, header, "30000", false)
+		require.Equal(t, "AbortError", result.Error)
+		require.Equal(t, 30000, result.Progress)
+	})
+
+	t.Run("incorrect header", func(t *testing.T) {
+		result := solve(t, "2:5:resource", "", false)
+		require.Equal(t, "Error", result.Error)
+	})
+}
+
+// findWasmExec - returns path of wasm_exec.js of go distribution
+func findWasmExec(t *testing.T) string {
+	goroot, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		t.Skip("go is not found")
+	}
+
+	for _, dir := range []string{"lib", "misc"} {
+		path := filepath.Join(strings.TrimSpace(string(goroot)), dir, "wasm", "wasm_exec.js")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	t.Skip("wasm_exec.js is not found")
+	return ""
+}
+
+// buildWasm - build solver to temp dir
+func buildWasm(t *testing.T) string {
+	wasmFile := filepath.Join(t.TempDir(), "hashcash.wasm")
+
+	cmd := exec.Command("go", "build", "-o", wasmFile, ".")
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return wasmFile
+}
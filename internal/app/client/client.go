@@ -1,32 +1,138 @@
 package client
 
 import (
+	"context"
+	"crypto/tls"
 	"net"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/websocket"
 )
 
+const networkWebSocket = "ws"
+
 // Opts - connection options
+// Retry - policy to reconnect and request a fresh puzzle if attempt fails, single attempt if nil
 type Opts struct {
-	Config  Config
-	Logger  Logger
-	Service Service
+	Config    Config
+	Logger    Logger
+	Service   Service
+	TLSConfig *tls.Config
+	Retry     Retry
+}
+
+// Connect - connect to server and request resource
+// Connection is established over tls if opts.TLSConfig is set,
+// websocket connection uses it for wss urls only.
+// Context cancellation interrupts dialing and pending reads
+func Connect(ctx context.Context, opts Opts) (resource string, err error) {
+	conn, resource, err := connectWithRetry(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	conn.Close()
+
+	return resource, nil
 }
 
-// Connect - connect to server
-func Connect(opts Opts) error {
-	const op = "client.Connect"
+// connectWithRetry - connect using retry policy if it's set
+// Every attempt uses new connection and solves a fresh puzzle
+func connectWithRetry(ctx context.Context, opts Opts) (conn net.Conn, resource string, err error) {
+	if opts.Retry == nil {
+		return connect(ctx, opts)
+	}
 
-	conn, err := net.Dial("tcp", opts.Config.ServerAddress())
+	err = opts.Retry.Do(ctx, func(ctx context.Context) (err error) {
+		conn, resource, err = connect(ctx, opts)
+		return
+	})
+
+	return conn, resource, err
+}
+
+// connect - connect to server and pass proof of work
+// Returns connection which can be used after protocol is completed, e.g. in proxy mode
+func connect(ctx context.Context, opts Opts) (net.Conn, string, error) {
+	const op = "client.connect"
+
+	conn, err := dial(ctx, opts)
 	if err != nil {
 		opts.Logger.Error(err.Error(), "op", op)
-		return err
+		return nil, "", err
+	}
+
+	stop := interruptOnDone(ctx, conn)
+	defer stop()
+
+	if opts.TLSConfig != nil && opts.Config.ServerNetwork() != networkWebSocket {
+		tlsConn := tls.Client(conn, tlsConfigFor(opts.TLSConfig, opts.Config.ServerAddress()))
+
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			tlsConn.Close()
+			opts.Logger.Error(err.Error(), "op", op)
+			return nil, "", err
+		}
+		conn = tlsConn
 	}
 
-	defer conn.Close()
+	// Messages are read through buffer, buffered data following them is forwarded
+	conn = message.NewConn(conn)
 
-	_, err = opts.Service.RequestResource(conn.LocalAddr().String(), conn)
+	resource, err := opts.Service.RequestResource(ctx, conn.LocalAddr().String(), conn)
 	if err != nil {
-		return err
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		return nil, "", err
+	}
+
+	return conn, resource, nil
+}
+
+// dial - connect to server address
+// Websocket server address is ws:// or wss:// url, tls is used by wss only
+func dial(ctx context.Context, opts Opts) (net.Conn, error) {
+	if opts.Config.ServerNetwork() == networkWebSocket {
+		conn, err := websocket.Dial(ctx, opts.Config.ServerAddress(), opts.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
 	}
 
-	return nil
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, opts.Config.ServerNetwork(), opts.Config.ServerAddress())
+}
+
+// tlsConfigFor - set server name from address if it's not configured
+func tlsConfigFor(config *tls.Config, address string) *tls.Config {
+	if config.ServerName != "" || config.InsecureSkipVerify {
+		return config
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || host == "" {
+		return config
+	}
+
+	config = config.Clone()
+	config.ServerName = host
+
+	return config
+}
+
+// interruptOnDone - unblock pending reads and writes when context is done
+func interruptOnDone(ctx context.Context, conn net.Conn) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }
 }
@@ -0,0 +1,8 @@
+package client
+
+import "errors"
+
+// Errors
+var (
+	ErrUnknownRetryCategory = errors.New("unknown retry category")
+)
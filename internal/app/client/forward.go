@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/pipe"
+)
+
+// Forward - accept local connections and forward them to server in proxy mode
+// Every local connection gets own server connection, which passes proof of work
+// and then is piped to local one.
+// Returns when listener fails or context is done, waits for forwarded connections to finish
+func Forward(ctx context.Context, opts Opts, listener net.Listener) error {
+	const op = "client.Forward"
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			listener.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			opts.Logger.Error(err.Error(), "op", op)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			forwardConnection(ctx, opts, local)
+		}()
+	}
+}
+
+// forwardConnection - pass proof of work and pipe local connection to server
+func forwardConnection(ctx context.Context, opts Opts, local net.Conn) {
+	const op = "client.forwardConnection"
+	defer local.Close()
+
+	localID := local.RemoteAddr().String()
+
+	remote, _, err := connectWithRetry(ctx, opts)
+	if err != nil {
+		opts.Logger.Error(err.Error(), "op", op, "localID", localID)
+		return
+	}
+	defer remote.Close()
+
+	opts.Logger.Debug("forwarding started", "op", op, "localID", localID)
+
+	if err := pipe.Pipe(ctx, local, remote); err != nil && ctx.Err() == nil {
+		opts.Logger.Info("forwarding failed", "op", op, "localID", localID, "error", err.Error())
+		return
+	}
+
+	opts.Logger.Debug("forwarding finished", "op", op, "localID", localID)
+}
@@ -1,12 +1,14 @@
 package client
 
 import (
+	"context"
 	"io"
 )
 
 // Config - config interface
 type Config interface {
 	ServerAddress() string
+	ServerNetwork() string
 }
 
 // Logger - logger interface
@@ -17,7 +19,12 @@ type Logger interface {
 	Debug(msg string, args ...any)
 }
 
+// Retry - retry policy of connection attempts
+type Retry interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // Service - clisnt service to get sever resource
 type Service interface {
-	RequestResource(clientID string, rw io.ReadWriter) (resource string, err error)
+	RequestResource(ctx context.Context, clientID string, rw io.ReadWriter) (resource string, err error)
 }
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/service"
+)
+
+// Categories of retryable errors
+const (
+	// RetryExpired - puzzle expired before solution was redeemed
+	RetryExpired = "expired"
+	// RetryTimeout - server connection timeout exceeded
+	RetryTimeout = "timeout"
+	// RetryBusy - server is shutting down or connection limits are exceeded
+	RetryBusy = "busy"
+	// RetryNetwork - connection failed or was dropped
+	RetryNetwork = "network"
+)
+
+// DefaultRetryCategories - categories retried by default
+var DefaultRetryCategories = []string{RetryExpired, RetryTimeout, RetryBusy, RetryNetwork}
+
+var retryErrors = map[string][]error{
+	RetryExpired: {service.ErrHashcashExpirationExceeded},
+	RetryTimeout: {service.ErrTimeoutExceeded},
+	RetryBusy: {
+		service.ErrServerShuttingDown,
+		service.ErrTooManyConnections,
+		service.ErrTooManyConnectionsFromIP,
+	},
+	RetryNetwork: {io.EOF, io.ErrUnexpectedEOF, net.ErrClosed},
+}
+
+// Retryable - returns function which reports if error belongs to one of categories
+func Retryable(categories []string) (func(err error) bool, error) {
+	var (
+		errs    []error
+		network bool
+	)
+
+	for _, category := range categories {
+		categoryErrs, ok := retryErrors[category]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRetryCategory, category)
+		}
+		errs = append(errs, categoryErrs...)
+		network = network || category == RetryNetwork
+	}
+
+	return func(err error) bool {
+		for _, target := range errs {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+
+		var netErr net.Error
+		return network && errors.As(err, &netErr)
+	}, nil
+}
@@ -1,15 +1,36 @@
 package server
 
 import (
+	"context"
 	"io"
+	"net"
 	"time"
 )
 
 // Config - config interface
 type Config interface {
 	Address() string
+	Listeners() []ListenerConfig
 	ShutdownTimeout() time.Duration
 	ConnectionTimeout() time.Duration
+	MaxConnections() int
+	MaxConnectionsPerIP() int
+	ConnectionQueueSize() int
+	ConnectionQueueTimeout() time.Duration
+	ProxyProtocol() bool
+	TrustedProxyCIDRs() []string
+	BackendAddress() string
+}
+
+// ListenerConfig - config of a single listener
+// Network - tcp, tcp4, tcp6, unix or ws (websocket over tcp, Path is http path).
+// ConnectionTimeout, PuzzleZeroBits - override server defaults if greater than zero
+type ListenerConfig struct {
+	Network           string
+	Address           string
+	Path              string
+	ConnectionTimeout time.Duration
+	PuzzleZeroBits    int
 }
 
 // Logger - logger interface
@@ -20,7 +41,14 @@ type Logger interface {
 	Debug(msg string, args ...any)
 }
 
+// IPFilter - filter of client ips
+type IPFilter interface {
+	Check(ip net.IP) error
+}
+
 // Service - server service to handle client messages
 type Service interface {
-	HandleMessages(clientID string, rw io.ReadWriter)
+	HandleMessages(ctx context.Context, clientID string, rw io.ReadWriter) (passed bool)
+	Reject(ctx context.Context, clientID string, reason error, w io.Writer)
+	Drain()
 }
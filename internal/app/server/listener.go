@@ -0,0 +1,112 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/websocket"
+)
+
+const (
+	networkUnix      = "unix"
+	networkWebSocket = "ws"
+)
+
+// listener - network listener with its own config
+type listener struct {
+	net.Listener
+	config ListenerConfig
+
+	// unixConns - sequence to identify unix socket clients
+	unixConns atomic.Uint64
+}
+
+// newListener - open listener
+// Websocket listener serves handshake over tls if tlsConfig is set
+func newListener(config ListenerConfig, tlsConfig *tls.Config) (*listener, error) {
+	if config.Network == networkWebSocket {
+		l, err := websocket.Listen(config.Address, config.Path, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return &listener{Listener: l, config: config}, nil
+	}
+
+	if config.Network == networkUnix {
+		if err := removeStaleSocket(config.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen(config.Network, config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listener{Listener: l, config: config}, nil
+}
+
+// Accept - accept connection
+// Unix socket clients have no address, so they get unique one
+// to be distinguished in logs, limits and puzzles
+func (l *listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil || l.config.Network != networkUnix {
+		return conn, err
+	}
+
+	name := l.config.Address + "#" + strconv.FormatUint(l.unixConns.Add(1), 10)
+	return &unixConn{
+		Conn: conn,
+		addr: &net.UnixAddr{Name: name, Net: "unix"},
+	}, nil
+}
+
+// String - returns listener network and address
+func (l *listener) String() string {
+	if l.isWebSocket() {
+		return l.config.Network + "://" + l.Addr().String() + l.config.Path
+	}
+	return l.config.Network + "://" + l.Addr().String()
+}
+
+// isWebSocket - check if listener accepts websocket connections
+// Such connections are already served over tls if it's enabled
+func (l *listener) isWebSocket() bool {
+	return l.config.Network == networkWebSocket
+}
+
+type unixConn struct {
+	net.Conn
+	addr net.Addr
+}
+
+func (c *unixConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// removeStaleSocket - remove socket file left by previous run
+// Files which are not sockets and sockets in use are kept
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil
+	}
+
+	return os.Remove(path)
+}
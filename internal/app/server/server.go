@@ -2,56 +2,151 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"net"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cidr"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/difficulty"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/limit"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/peer"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/pipe"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/proxyproto"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/websocket"
+	"github.com/pvarentsov/powtcp/internal/pkg/service"
+)
+
+const (
+	rejectWriteTimeout = 100 * time.Millisecond
+	backendDialTimeout = 5 * time.Second
+	forceCloseTimeout  = time.Second
 )
 
-// Listen - listen tcp connections
+// Listen - listen connections on all configured listeners
+// Listens config address over tcp if no listeners are configured.
+// Connections are served over tls if opts.TLSConfig is set.
+// Connections from trusted proxies must start with PROXY protocol header if it's enabled.
+// Context cancellation closes all connections immediately,
+// use Shutdown to close them gracefully
 func Listen(ctx context.Context, opts Opts) (server *Server, err error) {
-	listener, err := net.Listen("tcp", opts.Config.Address())
+	trustedProxies, err := cidr.ParseList(opts.Config.TrustedProxyCIDRs())
 	if err != nil {
 		return server, err
 	}
 
+	listeners, err := listen(opts.Config, opts.TLSConfig)
+	if err != nil {
+		return server, err
+	}
+
+	connCtx, cancelConns := context.WithCancel(ctx)
+
 	server = &Server{
-		listener: listener,
-		config:   opts.Config,
-		logger:   opts.Logger,
-		service:  opts.Service,
+		listeners:   listeners,
+		config:      opts.Config,
+		logger:      opts.Logger,
+		service:     opts.Service,
+		tlsConfig:   opts.TLSConfig,
+		ipFilter:    opts.IPFilter,
+		cancelConns: cancelConns,
+		conns:       make(map[net.Conn]struct{}),
+
+		trustedProxies: trustedProxies,
+		limiter: limit.New(limit.Opts{
+			Max:       opts.Config.MaxConnections(),
+			MaxPerKey: opts.Config.MaxConnectionsPerIP(),
+		}),
 	}
 
-	server.shutdownWg.Add(1)
-	go server.acceptConnections(ctx)
+	for _, l := range listeners {
+		server.shutdownWg.Add(1)
+		go server.acceptConnections(connCtx, l)
+	}
 
 	return server, nil
 }
 
+// listen - open all configured listeners
+// Already opened listeners are closed if one of them fails
+func listen(config Config, tlsConfig *tls.Config) ([]*listener, error) {
+	configs := config.Listeners()
+	if len(configs) == 0 {
+		configs = []ListenerConfig{{Network: "tcp", Address: config.Address()}}
+	}
+
+	listeners := make([]*listener, 0, len(configs))
+	for _, c := range configs {
+		l, err := newListener(c, tlsConfig)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+
+	return listeners, nil
+}
+
 // Opts - options to run server
 type Opts struct {
-	Config  Config
-	Logger  Logger
-	Service Service
+	Config    Config
+	Logger    Logger
+	Service   Service
+	TLSConfig *tls.Config
+	IPFilter  IPFilter
 }
 
 // Sever - tcp server
 type Server struct {
-	listener net.Listener
-	config   Config
-	logger   Logger
-	service  Service
+	listeners []*listener
+	config    Config
+	logger    Logger
+	service   Service
+	tlsConfig *tls.Config
+	ipFilter  IPFilter
+
+	trustedProxies cidr.List
+
+	activeConns   atomic.Int64
+	queuedConns   atomic.Int64
+	rejectedConns atomic.Int64
+	cancelConns   context.CancelFunc
+	limiter       *limit.Limiter
+
+	conns   map[net.Conn]struct{}
+	connsMu sync.Mutex
 
 	shutdownWg    sync.WaitGroup
 	isShutingDown atomic.Bool
 }
 
+// Addrs - returns addresses of all listeners
+func (s *Server) Addrs() []string {
+	addrs := make([]string, 0, len(s.listeners))
+	for _, l := range s.listeners {
+		addrs = append(addrs, l.String())
+	}
+	return addrs
+}
+
 // Shutdown - shutdown server gracefully
+// Server stops accepting connections and issuing new puzzles,
+// but lets connected clients redeem already issued puzzles.
+// Connections still open after shutdown timeout are closed forcibly.
 func (s *Server) Shutdown() {
 	const op = "server.Shutdown"
 
 	s.isShutingDown.Store(true)
-	s.listener.Close()
+	for _, l := range s.listeners {
+		l.Close()
+	}
+	s.service.Drain()
 
 	done := make(chan struct{})
 	go func() {
@@ -61,44 +156,319 @@ func (s *Server) Shutdown() {
 
 	select {
 	case <-done:
-		s.logger.Debug("shutdown server gracefully", "op", op)
+		s.cancelConns()
+		s.logger.Debug("shutdown server gracefully", "op", op, "rejected_connections", s.rejectedConns.Load())
 		return
 	case <-time.After(s.config.ShutdownTimeout()):
-		s.logger.Debug("shutdown server by timeout", "op", op)
+		closed := s.activeConns.Load()
+		s.cancelConns()
+		s.closeConns()
+
+		// Closed connections unblock handlers, but they may still be busy, e.g. with resource handler
+		select {
+		case <-done:
+		case <-time.After(forceCloseTimeout):
+			s.logger.Warn("connections not finished after force close", "op", op, "active_connections", s.activeConns.Load())
+		}
+
+		s.logger.Debug("shutdown server by timeout", "op", op, "closed_connections", closed, "rejected_connections", s.rejectedConns.Load())
 		return
 	}
 }
 
-func (s *Server) acceptConnections(ctx context.Context) {
+// closeConns - close active connections, it interrupts pending reads and writes
+func (s *Server) closeConns() {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// trackConn - remember active connection to close it on forced shutdown
+// Returns func to forget connection
+func (s *Server) trackConn(conn net.Conn) (untrack func()) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	s.conns[conn] = struct{}{}
+
+	return func() {
+		s.connsMu.Lock()
+		defer s.connsMu.Unlock()
+
+		delete(s.conns, conn)
+	}
+}
+
+func (s *Server) acceptConnections(ctx context.Context, l *listener) {
 	const op = "server.acceptConnections"
 	defer s.shutdownWg.Done()
 
 	for {
-		conn, err := s.listener.Accept()
+		conn, err := l.Accept()
 		if err != nil {
 			if s.isShutingDown.Load() {
-				s.logger.Debug("server closed", "op", op)
+				s.logger.Debug("listener closed", "op", op, "listener", l.String())
 				return
 			}
 
-			s.logger.Error(err.Error(), "op", op)
+			s.logger.Error(err.Error(), "op", op, "listener", l.String())
 			continue
 		}
 
-		go s.handleConnection(conn)
+		if s.isProxied(l, conn) {
+			s.shutdownWg.Add(1)
+			go func() {
+				defer s.shutdownWg.Done()
+				s.acceptProxiedConnection(ctx, l, conn)
+			}()
+			continue
+		}
+
+		s.serveConnection(ctx, l, conn)
+	}
+}
+
+// isProxied - check if connection is accepted from trusted proxy
+// Websocket connections never start with PROXY protocol header
+func (s *Server) isProxied(l *listener, conn net.Conn) bool {
+	if !s.config.ProxyProtocol() || l.isWebSocket() {
+		return false
+	}
+	return s.trustedProxies.Contains(peer.Peer{Addr: conn.RemoteAddr()}.IP())
+}
+
+// acceptProxiedConnection - read PROXY protocol header and serve connection with real client address
+func (s *Server) acceptProxiedConnection(ctx context.Context, l *listener, conn net.Conn) {
+	const op = "server.acceptProxiedConnection"
+
+	conn.SetReadDeadline(time.Now().Add(s.connectionTimeout(l)))
+
+	proxiedConn, err := proxyproto.NewConn(conn)
+	if err != nil {
+		s.logger.Info("proxy protocol header not read", "op", op, "proxy", conn.RemoteAddr().String(), "error", err.Error())
+		conn.Close()
+		return
+	}
+
+	conn.SetReadDeadline(time.Time{})
+	s.serveConnection(ctx, l, proxiedConn)
+}
+
+// serveConnection - check connection by ip filter and limits and handle it in background
+func (s *Server) serveConnection(ctx context.Context, l *listener, conn net.Conn) {
+	if err := s.ipFilter.Check(peer.Peer{Addr: conn.RemoteAddr()}.IP()); err != nil {
+		s.rejectConnection(ctx, l, conn, err)
+		return
+	}
+
+	ip := remoteIP(conn)
+	if err := s.limiter.TryAcquire(ip); err != nil {
+		if !s.enqueueConnection(ctx, l, conn, ip) {
+			s.rejectConnection(ctx, l, conn, err)
+		}
+		return
 	}
+
+	s.shutdownWg.Add(1)
+	go func() {
+		defer s.shutdownWg.Done()
+		s.handleConnection(ctx, l, conn, ip)
+	}()
 }
 
-func (s *Server) handleConnection(conn net.Conn) {
-	const op = "server.handleConnection"
+// enqueueConnection - wait for free connection slot in background
+// Returns false if queue is disabled or full
+func (s *Server) enqueueConnection(ctx context.Context, l *listener, conn net.Conn, ip string) bool {
+	timeout := s.config.ConnectionQueueTimeout()
+	if timeout <= 0 {
+		return false
+	}
+	if s.queuedConns.Add(1) > int64(s.config.ConnectionQueueSize()) {
+		s.queuedConns.Add(-1)
+		return false
+	}
+
+	s.shutdownWg.Add(1)
+	go func() {
+		defer s.shutdownWg.Done()
+
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := s.limiter.Acquire(waitCtx, ip)
+		cancel()
+		s.queuedConns.Add(-1)
+
+		if err != nil {
+			s.rejectConnection(ctx, l, conn, err)
+			return
+		}
+
+		s.handleConnection(ctx, l, conn, ip)
+	}()
+
+	return true
+}
+
+// rejectConnection - send error to client and close connection
+func (s *Server) rejectConnection(ctx context.Context, l *listener, conn net.Conn, err error) {
+	const op = "server.rejectConnection"
+	defer conn.Close()
+
+	reason := err
+	if errors.Is(err, limit.ErrLimitExceeded) {
+		reason = service.ErrTooManyConnections
+	}
+	if errors.Is(err, limit.ErrKeyLimitExceeded) {
+		reason = service.ErrTooManyConnectionsFromIP
+	}
+
+	clientID := conn.RemoteAddr().String()
+	rejected := s.rejectedConns.Add(1)
+	s.logger.Warn("connection rejected", "op", op, "clientID", clientID, "reason", reason.Error(),
+		"active_connections", s.activeConns.Load(),
+		"rejected_connections", rejected,
+	)
+
+	// Error message can't be sent before tls handshake,
+	// and handshake is too expensive for over-limit connections
+	if s.tlsConfig != nil && !l.isWebSocket() {
+		return
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(rejectWriteTimeout))
+	s.service.Reject(ctx, clientID, reason, conn)
+}
+
+func (s *Server) handleConnection(ctx context.Context, l *listener, conn net.Conn, ip string) {
 	defer conn.Close()
+	defer s.limiter.Release(ip)
+
+	s.activeConns.Add(1)
+	defer s.activeConns.Add(-1)
+
+	untrack := s.trackConn(conn)
+	defer untrack()
+
+	conn, passed := s.handleMessages(ctx, l, conn)
+	defer conn.Close()
+
+	if passed && s.config.BackendAddress() != "" {
+		s.proxyConnection(ctx, conn)
+	}
+}
 
-	conn.SetReadDeadline(time.Now().Add(s.config.ConnectionTimeout()))
+// handleMessages - run protocol with client within connection timeout
+// Returns connection wrapped with tls if it's enabled and true if client passed proof of work
+func (s *Server) handleMessages(ctx context.Context, l *listener, conn net.Conn) (net.Conn, bool) {
+	const op = "server.handleMessages"
+
+	ctx, cancel := context.WithTimeout(ctx, s.connectionTimeout(l))
+	defer cancel()
+
+	stop := interruptOnDone(ctx, conn)
+	defer stop()
 
 	if s.isShutingDown.Load() {
 		s.logger.Error("server closed", "op", op)
+		return conn, false
+	}
+
+	clientID := conn.RemoteAddr().String()
+	client := peer.Peer{Addr: conn.RemoteAddr()}
+
+	if wsConn, ok := conn.(*websocket.Conn); ok {
+		client.TLS = wsConn.TLS()
+	}
+
+	if s.tlsConfig != nil && !l.isWebSocket() {
+		tlsConn := tls.Server(conn, s.tlsConfig)
+
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			s.logger.Info("tls handshake failed", "op", op, "clientID", clientID, "error", err.Error())
+			return tlsConn, false
+		}
+
+		state := tlsConn.ConnectionState()
+		client.TLS = &state
+		conn = tlsConn
+	}
+
+	ctx = peer.NewContext(ctx, client)
+	if l.config.PuzzleZeroBits > 0 {
+		ctx = difficulty.NewContext(ctx, l.config.PuzzleZeroBits)
+	}
+
+	// Messages are read through buffer, buffered data following them is proxied
+	conn = message.NewConn(conn)
+
+	return conn, s.service.HandleMessages(ctx, clientID, conn)
+}
+
+// proxyConnection - splice client connection to backend
+// Connection timeout isn't applied, proxying lasts until one of the sides closes connection
+// or connections are closed on shutdown
+func (s *Server) proxyConnection(ctx context.Context, conn net.Conn) {
+	const op = "server.proxyConnection"
+
+	clientID := conn.RemoteAddr().String()
+	conn.SetDeadline(time.Time{})
+
+	dialer := net.Dialer{Timeout: backendDialTimeout}
+	backend, err := dialer.DialContext(ctx, "tcp", s.config.BackendAddress())
+	if err != nil {
+		s.logger.Error(err.Error(), "op", op, "clientID", clientID)
+		return
+	}
+	defer backend.Close()
+
+	s.logger.Debug("proxying started", "op", op, "clientID", clientID, "backend", s.config.BackendAddress())
+
+	if err := pipe.Pipe(ctx, conn, backend); err != nil && ctx.Err() == nil {
+		s.logger.Info("proxying failed", "op", op, "clientID", clientID, "error", err.Error())
 		return
 	}
 
-	s.service.HandleMessages(conn.RemoteAddr().String(), conn)
+	s.logger.Debug("proxying finished", "op", op, "clientID", clientID)
+}
+
+// connectionTimeout - returns listener connection timeout or server default
+func (s *Server) connectionTimeout(l *listener) time.Duration {
+	if l.config.ConnectionTimeout > 0 {
+		return l.config.ConnectionTimeout
+	}
+	return s.config.ConnectionTimeout()
+}
+
+// interruptOnDone - unblock pending reads when context is done
+// Writes stay available, so service is able to send an error to client
+func interruptOnDone(ctx context.Context, conn net.Conn) (stop func()) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// remoteIP - returns ip of connection remote address
+// Returns the whole address if it has no port, e.g. unix socket
+func remoteIP(conn net.Conn) string {
+	addr := conn.RemoteAddr().String()
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
 }
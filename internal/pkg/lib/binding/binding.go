@@ -0,0 +1,110 @@
+package binding
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Policy - policy to bind puzzle to client
+type Policy string
+
+// Policy - supported policies
+const (
+	// PolicyAddress - bind puzzle to full client address including port
+	PolicyAddress Policy = "address"
+
+	// PolicyIP - bind puzzle to client ip, so puzzle can be redeemed on a new connection
+	PolicyIP Policy = "ip"
+
+	// PolicyPrefix - bind puzzle to client network, e.g. /24 for ipv4 and /64 for ipv6
+	PolicyPrefix Policy = "prefix"
+
+	// PolicyClient - bind puzzle to identity supplied by client in puzzle request
+	PolicyClient Policy = "client"
+)
+
+const maxIdentityLength = 256
+
+// Opts - options to create new binder instance
+// PrefixV4, PrefixV6 - network prefix lengths, uses by prefix policy
+type Opts struct {
+	Policy   Policy
+	PrefixV4 int
+	PrefixV6 int
+}
+
+// New - create new binder instance
+func New(opts Opts) (*Binder, error) {
+	switch opts.Policy {
+	case PolicyAddress, PolicyIP, PolicyClient:
+	case PolicyPrefix:
+		if opts.PrefixV4 < 0 || opts.PrefixV4 > 32 || opts.PrefixV6 < 0 || opts.PrefixV6 > 128 {
+			return nil, ErrIncorrectPrefix
+		}
+	default:
+		return nil, ErrUnknownPolicy
+	}
+
+	return &Binder{
+		policy:   opts.Policy,
+		prefixV4: opts.PrefixV4,
+		prefixV6: opts.PrefixV6,
+	}, nil
+}
+
+// Binder - computes puzzle resource by binding policy
+type Binder struct {
+	policy   Policy
+	prefixV4 int
+	prefixV6 int
+}
+
+// Policy - returns binding policy
+func (b *Binder) Policy() Policy {
+	return b.policy
+}
+
+// Resource - returns puzzle resource for client
+// clientID - client address, identity - identity supplied by client
+// Address without ip, e.g. unix socket, is used as is
+func (b *Binder) Resource(clientID string, identity string) (string, error) {
+	switch b.policy {
+	case PolicyClient:
+		if identity == "" {
+			return "", ErrIdentityRequired
+		}
+		if len(identity) > maxIdentityLength || strings.ContainsAny(identity, "\r\n") {
+			return "", ErrIncorrectIdentity
+		}
+		return identity, nil
+	case PolicyIP:
+		if ip := parseIP(clientID); ip != nil {
+			return ip.String(), nil
+		}
+	case PolicyPrefix:
+		if ip := parseIP(clientID); ip != nil {
+			return b.prefix(ip), nil
+		}
+	}
+
+	return clientID, nil
+}
+
+func (b *Binder) prefix(ip net.IP) string {
+	bits, size := b.prefixV6, 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits, size = ip4, b.prefixV4, 32
+	}
+
+	network := ip.Mask(net.CIDRMask(bits, size))
+	return network.String() + "/" + strconv.Itoa(bits)
+}
+
+func parseIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,74 @@
+package binding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Binder(t *testing.T) {
+	t.Run("address ok", func(t *testing.T) {
+		b, err := New(Opts{Policy: PolicyAddress})
+		require.NoError(t, err)
+
+		act, err := b.Resource("192.168.0.1:56324", "ignored")
+		require.NoError(t, err)
+		require.Equal(t, "192.168.0.1:56324", act)
+	})
+
+	t.Run("ip ok", func(t *testing.T) {
+		b, err := New(Opts{Policy: PolicyIP})
+		require.NoError(t, err)
+
+		act, err := b.Resource("192.168.0.1:56324", "")
+		require.NoError(t, err)
+		require.Equal(t, "192.168.0.1", act)
+
+		act, err = b.Resource("[2001:db8::1]:56324", "")
+		require.NoError(t, err)
+		require.Equal(t, "2001:db8::1", act)
+
+		// Unix socket address has no ip
+		act, err = b.Resource("@", "")
+		require.NoError(t, err)
+		require.Equal(t, "@", act)
+	})
+
+	t.Run("prefix ok", func(t *testing.T) {
+		b, err := New(Opts{Policy: PolicyPrefix, PrefixV4: 24, PrefixV6: 64})
+		require.NoError(t, err)
+
+		act, err := b.Resource("192.168.0.17:56324", "")
+		require.NoError(t, err)
+		require.Equal(t, "192.168.0.0/24", act)
+
+		act, err = b.Resource("[2001:db8:1:2:3:4:5:6]:56324", "")
+		require.NoError(t, err)
+		require.Equal(t, "2001:db8:1:2::/64", act)
+
+		// Ipv4-mapped ipv6 address is handled as ipv4
+		act, err = b.Resource("[::ffff:192.168.0.17]:56324", "")
+		require.NoError(t, err)
+		require.Equal(t, "192.168.0.0/24", act)
+	})
+
+	t.Run("client ok", func(t *testing.T) {
+		b, err := New(Opts{Policy: PolicyClient})
+		require.NoError(t, err)
+
+		act, err := b.Resource("192.168.0.1:56324", "user@example.com")
+		require.NoError(t, err)
+		require.Equal(t, "user@example.com", act)
+
+		_, err = b.Resource("192.168.0.1:56324", "")
+		require.EqualError(t, ErrIdentityRequired, err.Error())
+	})
+
+	t.Run("new failed", func(t *testing.T) {
+		_, err := New(Opts{Policy: "unknown"})
+		require.EqualError(t, ErrUnknownPolicy, err.Error())
+
+		_, err = New(Opts{Policy: PolicyPrefix, PrefixV4: 33, PrefixV6: 64})
+		require.EqualError(t, ErrIncorrectPrefix, err.Error())
+	})
+}
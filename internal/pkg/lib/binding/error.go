@@ -0,0 +1,11 @@
+package binding
+
+import "errors"
+
+// Errors
+var (
+	ErrUnknownPolicy     = errors.New("unknown binding policy")
+	ErrIncorrectPrefix   = errors.New("incorrect binding prefix")
+	ErrIdentityRequired  = errors.New("client identity required")
+	ErrIncorrectIdentity = errors.New("incorrect client identity")
+)
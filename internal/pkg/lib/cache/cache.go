@@ -62,6 +62,20 @@ func (c *Cache[K, V]) Delete(k K) {
 	delete(c.cache, k)
 }
 
+// Take - delete actual value by key and report whether it was in cache,
+// so only one of concurrent callers takes the value
+func (c *Cache[K, V]) Take(k K) (ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.cache[k]
+	if ok {
+		delete(c.cache, k)
+	}
+
+	return ok && value.actual()
+}
+
 // Add - get actual value by key
 func (c *Cache[K, V]) Get(k K) (v V, ok bool) {
 	c.mu.Lock()
@@ -51,4 +51,17 @@ func Test_Cache(t *testing.T) {
 		time.Sleep(50 * time.Millisecond)
 		require.True(t, logger.cancelSignalHandled)
 	})
+
+	t.Run("Take ok", func(t *testing.T) {
+		c := New[string, struct{}](context.Background(), Opts{})
+
+		c.AddWithExp("1", struct{}{}, time.Now().Add(time.Minute))
+		c.AddWithExp("2", struct{}{}, time.Now().Add(-time.Minute))
+
+		require.True(t, c.Take("1"))
+		require.False(t, c.Take("1"))
+		require.False(t, c.Take("2"))
+		require.False(t, c.Take("3"))
+		require.Equal(t, 0, len(c.cache))
+	})
 }
@@ -0,0 +1,224 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ServerOpts - options to create server tls config
+// ClientCAFile - enables mutual tls if value is not empty
+// ReloadInterval - uses if value > 0
+type ServerOpts struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string
+	ReloadInterval time.Duration
+	Logger         Logger
+}
+
+// NewServerConfig - create server tls config with hot reloaded certificate
+func NewServerConfig(ctx context.Context, opts ServerOpts) (*tls.Config, error) {
+	keyPair, err := NewKeyPair(ctx, KeyPairOpts{
+		CertFile:       opts.CertFile,
+		KeyFile:        opts.KeyFile,
+		ReloadInterval: opts.ReloadInterval,
+		Logger:         opts.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: keyPair.GetCertificate,
+	}
+
+	if opts.ClientCAFile != "" {
+		pool, err := LoadCertPool(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// ClientOpts - options to create client tls config
+// CAFile - uses system roots if value is empty
+// CertFile, KeyFile - client certificate for mutual tls, uses if values are not empty
+// ReloadInterval - uses if value > 0
+type ClientOpts struct {
+	CAFile         string
+	CertFile       string
+	KeyFile        string
+	ServerName     string
+	ReloadInterval time.Duration
+	Logger         Logger
+}
+
+// NewClientConfig - create client tls config with hot reloaded certificate
+func NewClientConfig(ctx context.Context, opts ClientOpts) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: opts.ServerName,
+	}
+
+	if opts.CAFile != "" {
+		pool, err := LoadCertPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		keyPair, err := NewKeyPair(ctx, KeyPairOpts{
+			CertFile:       opts.CertFile,
+			KeyFile:        opts.KeyFile,
+			ReloadInterval: opts.ReloadInterval,
+			Logger:         opts.Logger,
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = keyPair.GetClientCertificate
+	}
+
+	return config, nil
+}
+
+// LoadCertPool - load PEM encoded certificates from file
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, ErrNoCertificatesFound
+	}
+
+	return pool, nil
+}
+
+// KeyPairOpts - options to create key pair
+// ReloadInterval - uses if value > 0
+type KeyPairOpts struct {
+	CertFile       string
+	KeyFile        string
+	ReloadInterval time.Duration
+	Logger         Logger
+}
+
+// NewKeyPair - load certificate key pair and reload it when files are changed
+func NewKeyPair(ctx context.Context, opts KeyPairOpts) (*KeyPair, error) {
+	kp := &KeyPair{
+		certFile: opts.CertFile,
+		keyFile:  opts.KeyFile,
+		logger:   opts.Logger,
+	}
+	if _, err := kp.Reload(); err != nil {
+		return nil, err
+	}
+	if opts.ReloadInterval > 0 {
+		go kp.runReloader(ctx, opts.ReloadInterval)
+	}
+
+	return kp, nil
+}
+
+// KeyPair - certificate key pair which can be reloaded at runtime
+type KeyPair struct {
+	certFile string
+	keyFile  string
+	logger   Logger
+
+	cert    atomic.Pointer[tls.Certificate]
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// Certificate - returns actual certificate
+func (kp *KeyPair) Certificate() *tls.Certificate {
+	return kp.cert.Load()
+}
+
+// GetCertificate - returns actual certificate, uses as tls.Config.GetCertificate
+func (kp *KeyPair) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return kp.cert.Load(), nil
+}
+
+// GetClientCertificate - returns actual certificate, uses as tls.Config.GetClientCertificate
+func (kp *KeyPair) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return kp.cert.Load(), nil
+}
+
+// Reload - load key pair if files were changed since last load
+// Keeps previous certificate if loading failed
+func (kp *KeyPair) Reload() (reloaded bool, err error) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	modTime, err := kp.lastModTime()
+	if err != nil {
+		return false, err
+	}
+	if !modTime.After(kp.modTime) {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(kp.certFile, kp.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	kp.cert.Store(&cert)
+	kp.modTime = modTime
+
+	return true, nil
+}
+
+func (kp *KeyPair) lastModTime() (modTime time.Time, err error) {
+	for _, path := range []string{kp.certFile, kp.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTime, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return
+}
+
+func (kp *KeyPair) runReloader(ctx context.Context, interval time.Duration) {
+	const op = "certs.runReloader"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			kp.logger.Debug("context canceled", "op", op)
+			return
+		case <-ticker.C:
+			reloaded, err := kp.Reload()
+			if err != nil {
+				kp.logger.Error(err.Error(), "op", op, "cert_file", kp.certFile)
+				continue
+			}
+			if reloaded {
+				kp.logger.Info("certificate reloaded", "op", op, "cert_file", kp.certFile)
+			}
+		}
+	}
+}
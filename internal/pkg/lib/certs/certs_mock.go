@@ -0,0 +1,7 @@
+package certs
+
+type mockLogger struct{}
+
+func (l *mockLogger) Info(msg string, args ...any)  {}
+func (l *mockLogger) Error(msg string, args ...any) {}
+func (l *mockLogger) Debug(msg string, args ...any) {}
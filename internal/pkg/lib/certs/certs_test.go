@@ -0,0 +1,241 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Config(t *testing.T) {
+	t.Run("mutual tls handshake ok", func(t *testing.T) {
+		dir := t.TempDir()
+		ca := newTestCA(t)
+		ca.writeCA(t, dir, "ca.pem")
+		ca.writeLeaf(t, dir, "server", "localhost")
+		ca.writeLeaf(t, dir, "client", "trusted-client")
+
+		serverConfig, err := NewServerConfig(context.Background(), ServerOpts{
+			CertFile:     filepath.Join(dir, "server.pem"),
+			KeyFile:      filepath.Join(dir, "server.key"),
+			ClientCAFile: filepath.Join(dir, "ca.pem"),
+			Logger:       &mockLogger{},
+		})
+		require.NoError(t, err)
+
+		clientConfig, err := NewClientConfig(context.Background(), ClientOpts{
+			CAFile:     filepath.Join(dir, "ca.pem"),
+			CertFile:   filepath.Join(dir, "client.pem"),
+			KeyFile:    filepath.Join(dir, "client.key"),
+			ServerName: "localhost",
+			Logger:     &mockLogger{},
+		})
+		require.NoError(t, err)
+
+		serverState, err := handshake(serverConfig, clientConfig)
+		require.NoError(t, err)
+		require.Len(t, serverState.PeerCertificates, 1)
+		require.Equal(t, "trusted-client", serverState.PeerCertificates[0].Subject.CommonName)
+	})
+
+	t.Run("mutual tls handshake without client certificate failed", func(t *testing.T) {
+		dir := t.TempDir()
+		ca := newTestCA(t)
+		ca.writeCA(t, dir, "ca.pem")
+		ca.writeLeaf(t, dir, "server", "localhost")
+
+		serverConfig, err := NewServerConfig(context.Background(), ServerOpts{
+			CertFile:     filepath.Join(dir, "server.pem"),
+			KeyFile:      filepath.Join(dir, "server.key"),
+			ClientCAFile: filepath.Join(dir, "ca.pem"),
+			Logger:       &mockLogger{},
+		})
+		require.NoError(t, err)
+
+		clientConfig, err := NewClientConfig(context.Background(), ClientOpts{
+			CAFile:     filepath.Join(dir, "ca.pem"),
+			ServerName: "localhost",
+		})
+		require.NoError(t, err)
+
+		_, err = handshake(serverConfig, clientConfig)
+		require.Error(t, err)
+	})
+
+	t.Run("load cert pool failed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "empty.pem")
+		require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+
+		_, err := LoadCertPool(path)
+		require.EqualError(t, ErrNoCertificatesFound, err.Error())
+	})
+}
+
+func Test_KeyPair(t *testing.T) {
+	t.Run("reload ok", func(t *testing.T) {
+		dir := t.TempDir()
+		ca := newTestCA(t)
+		ca.writeLeaf(t, dir, "server", "first")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kp, err := NewKeyPair(ctx, KeyPairOpts{
+			CertFile:       filepath.Join(dir, "server.pem"),
+			KeyFile:        filepath.Join(dir, "server.key"),
+			ReloadInterval: 20 * time.Millisecond,
+			Logger:         &mockLogger{},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "first", leafCommonName(t, kp.Certificate()))
+
+		// Files are not changed
+		reloaded, err := kp.Reload()
+		require.NoError(t, err)
+		require.False(t, reloaded)
+
+		// Certificate must be reloaded by reloader
+		ca.writeLeaf(t, dir, "server", "second")
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "server.pem"), future, future))
+
+		require.Eventually(t, func() bool {
+			return leafCommonName(t, kp.Certificate()) == "second"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("reload keeps previous certificate on error", func(t *testing.T) {
+		dir := t.TempDir()
+		ca := newTestCA(t)
+		ca.writeLeaf(t, dir, "server", "first")
+
+		kp, err := NewKeyPair(context.Background(), KeyPairOpts{
+			CertFile: filepath.Join(dir, "server.pem"),
+			KeyFile:  filepath.Join(dir, "server.key"),
+			Logger:   &mockLogger{},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "server.pem"), []byte("broken"), 0o600))
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "server.pem"), future, future))
+
+		reloaded, err := kp.Reload()
+		require.Error(t, err)
+		require.False(t, reloaded)
+		require.Equal(t, "first", leafCommonName(t, kp.Certificate()))
+	})
+}
+
+func handshake(serverConfig, clientConfig *tls.Config) (tls.ConnectionState, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	clientErr := make(chan error, 1)
+	go func() {
+		conn := tls.Client(clientConn, clientConfig)
+		err := conn.Handshake()
+		if err == nil {
+			// Client side completes handshake before server verifies client certificate
+			_, err = conn.Read(make([]byte, 1))
+		}
+		clientErr <- err
+	}()
+
+	server := tls.Server(serverConn, serverConfig)
+	if err := server.Handshake(); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	if _, err := server.Write([]byte{1}); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	if err := <-clientErr; err != nil {
+		return tls.ConnectionState{}, err
+	}
+
+	return server.ConnectionState(), nil
+}
+
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, der: der}
+}
+
+func (ca *testCA) writeCA(t *testing.T, dir string, name string) {
+	writePEM(t, filepath.Join(dir, name), "CERTIFICATE", ca.der)
+}
+
+func (ca *testCA) writeLeaf(t *testing.T, dir string, name string, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	writePEM(t, filepath.Join(dir, name+".pem"), "CERTIFICATE", der)
+	writePEM(t, filepath.Join(dir, name+".key"), "EC PRIVATE KEY", keyDer)
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
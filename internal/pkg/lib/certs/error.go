@@ -0,0 +1,8 @@
+package certs
+
+import "errors"
+
+// Errors
+var (
+	ErrNoCertificatesFound = errors.New("no certificates found")
+)
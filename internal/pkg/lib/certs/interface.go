@@ -0,0 +1,8 @@
+package certs
+
+// Logger - logger interface
+type Logger interface {
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+	Debug(msg string, args ...any)
+}
@@ -0,0 +1,64 @@
+package cidr
+
+import (
+	"net"
+	"strings"
+)
+
+// Parse - parse network in CIDR notation
+// Single ip address is parsed as network with one address
+func Parse(cidr string) (*net.IPNet, error) {
+	cidr = strings.TrimSpace(cidr)
+
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, ErrIncorrectCIDR
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, ErrIncorrectCIDR
+	}
+	return network, nil
+}
+
+// ParseList - parse list of networks in CIDR notation
+func ParseList(cidrs []string) (List, error) {
+	list := make(List, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		if strings.TrimSpace(cidr) == "" {
+			continue
+		}
+
+		network, err := Parse(cidr)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, network)
+	}
+
+	return list, nil
+}
+
+// List - list of networks
+type List []*net.IPNet
+
+// Contains - check if any network contains ip
+func (l List) Contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range l {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
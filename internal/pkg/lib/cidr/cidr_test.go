@@ -0,0 +1,33 @@
+package cidr
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseList(t *testing.T) {
+	t.Run("parse and contains ok", func(t *testing.T) {
+		list, err := ParseList([]string{"10.0.0.0/8", " 192.168.1.10 ", "2001:db8::/32", "::1", ""})
+		require.NoError(t, err)
+		require.Len(t, list, 4)
+
+		require.True(t, list.Contains(net.ParseIP("10.1.2.3")))
+		require.True(t, list.Contains(net.ParseIP("192.168.1.10")))
+		require.True(t, list.Contains(net.ParseIP("2001:db8::42")))
+		require.True(t, list.Contains(net.ParseIP("::1")))
+
+		require.False(t, list.Contains(net.ParseIP("192.168.1.11")))
+		require.False(t, list.Contains(net.ParseIP("2001:db9::1")))
+		require.False(t, list.Contains(nil))
+	})
+
+	t.Run("parse failed", func(t *testing.T) {
+		_, err := ParseList([]string{"10.0.0.0/8", "10.0.0.0/33"})
+		require.EqualError(t, ErrIncorrectCIDR, err.Error())
+
+		_, err = ParseList([]string{"localhost"})
+		require.EqualError(t, ErrIncorrectCIDR, err.Error())
+	})
+}
@@ -0,0 +1,8 @@
+package cidr
+
+import "errors"
+
+// Errors
+var (
+	ErrIncorrectCIDR = errors.New("incorrect CIDR")
+)
@@ -11,6 +11,7 @@ type Config struct {
 	Server   `yaml:"server" env-prefix:"SERVER_"`
 	Client   `yaml:"client" env-prefix:"CLIENT_"`
 	Hashcash `yaml:"hashcash" env-prefix:"HASHCASH_"`
+	Trace    `yaml:"trace" env-prefix:"TRACE_"`
 }
 
 // Server - server config structure
@@ -20,29 +21,112 @@ type Server struct {
 	Address           string `yaml:"address" env:"ADDRESS" env-default:":8080"`
 	ShutdownTimeout   int    `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"1000"`
 	ConnectionTimeout int    `yaml:"connection_timeout" env:"CONNECTION_TIMEOUT" env-default:"30000"`
+
+	Listeners []Listener `yaml:"listeners"`
+
+	MaxConnections         int `yaml:"max_connections" env:"MAX_CONNECTIONS" env-default:"0"`
+	MaxConnectionsPerIP    int `yaml:"max_connections_per_ip" env:"MAX_CONNECTIONS_PER_IP" env-default:"0"`
+	ConnectionQueueSize    int `yaml:"connection_queue_size" env:"CONNECTION_QUEUE_SIZE" env-default:"0"`
+	ConnectionQueueTimeout int `yaml:"connection_queue_timeout" env:"CONNECTION_QUEUE_TIMEOUT" env-default:"0"`
+
+	TLSEnabled        bool   `yaml:"tls_enabled" env:"TLS_ENABLED" env-default:"false"`
+	TLSCertFile       string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile        string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	TLSClientCAFile   string `yaml:"tls_client_ca_file" env:"TLS_CLIENT_CA_FILE"`
+	TLSReloadInterval int    `yaml:"tls_reload_interval" env:"TLS_RELOAD_INTERVAL" env-default:"0"`
+
+	TrustedCIDRs     []string `yaml:"trusted_cidrs" env:"TRUSTED_CIDRS"`
+	TrustedAPIKeys   []string `yaml:"trusted_api_keys" env:"TRUSTED_API_KEYS"`
+	TrustedCertNames []string `yaml:"trusted_cert_names" env:"TRUSTED_CERT_NAMES"`
+	TrustedZeroBits  int      `yaml:"trusted_zero_bits" env:"TRUSTED_ZERO_BITS" env-default:"0"`
+
+	AllowCIDRs   []string `yaml:"allow_cidrs" env:"ALLOW_CIDRS"`
+	DenyCIDRs    []string `yaml:"deny_cidrs" env:"DENY_CIDRS"`
+	BanThreshold int      `yaml:"ban_threshold" env:"BAN_THRESHOLD" env-default:"0"`
+	BanWindow    int      `yaml:"ban_window" env:"BAN_WINDOW" env-default:"60000"`
+	BanDuration  int      `yaml:"ban_duration" env:"BAN_DURATION" env-default:"300000"`
+
+	ProxyProtocol     bool     `yaml:"proxy_protocol" env:"PROXY_PROTOCOL" env-default:"false"`
+	TrustedProxyCIDRs []string `yaml:"trusted_proxy_cidrs" env:"TRUSTED_PROXY_CIDRS"`
+
+	PuzzleBinding         string `yaml:"puzzle_binding" env:"PUZZLE_BINDING" env-default:"address"`
+	PuzzleBindingPrefixV4 int    `yaml:"puzzle_binding_prefix_v4" env:"PUZZLE_BINDING_PREFIX_V4" env-default:"24"`
+	PuzzleBindingPrefixV6 int    `yaml:"puzzle_binding_prefix_v6" env:"PUZZLE_BINDING_PREFIX_V6" env-default:"64"`
+
+	BackendAddress string `yaml:"backend_address" env:"BACKEND_ADDRESS"`
+}
+
+// Listener - server listener config structure
+// ConnectionTimeout, Bits - override server and hashcash defaults if greater than zero
+type Listener struct {
+	Network           string `yaml:"network"`
+	Address           string `yaml:"address"`
+	Path              string `yaml:"path"`
+	ConnectionTimeout int    `yaml:"connection_timeout"`
+	Bits              int    `yaml:"bits"`
 }
 
 // Client - client config structure
 type Client struct {
-	LogLevel      int    `yaml:"log_level" env:"LOG_LEVEL" env-default:"0"`
-	LogJson       bool   `yaml:"log_json" env:"LOG_JSON" env-default:"false"`
-	ServerAddress string `yaml:"server_address" env:"SERVER_ADDRESS" env-default:":8080"`
+	LogLevel       int    `yaml:"log_level" env:"LOG_LEVEL" env-default:"0"`
+	LogJson        bool   `yaml:"log_json" env:"LOG_JSON" env-default:"false"`
+	ServerAddress  string `yaml:"server_address" env:"SERVER_ADDRESS" env-default:":8080"`
+	ServerNetwork  string `yaml:"server_network" env:"SERVER_NETWORK" env-default:"tcp"`
+	APIKey         string `yaml:"api_key" env:"API_KEY"`
+	Identity       string `yaml:"identity" env:"IDENTITY"`
+	ForwardAddress string `yaml:"forward_address" env:"FORWARD_ADDRESS"`
+
+	RetryMaxAttempts    int      `yaml:"retry_max_attempts" env:"RETRY_MAX_ATTEMPTS" env-default:"1"`
+	RetryInitialBackoff int      `yaml:"retry_initial_backoff" env:"RETRY_INITIAL_BACKOFF" env-default:"100"`
+	RetryMaxBackoff     int      `yaml:"retry_max_backoff" env:"RETRY_MAX_BACKOFF" env-default:"5000"`
+	RetryJitter         float64  `yaml:"retry_jitter" env:"RETRY_JITTER" env-default:"0.2"`
+	RetryOn             []string `yaml:"retry_on" env:"RETRY_ON" env-default:"expired,timeout,busy,network"`
+
+	TLSEnabled        bool   `yaml:"tls_enabled" env:"TLS_ENABLED" env-default:"false"`
+	TLSCAFile         string `yaml:"tls_ca_file" env:"TLS_CA_FILE"`
+	TLSCertFile       string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile        string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	TLSServerName     string `yaml:"tls_server_name" env:"TLS_SERVER_NAME"`
+	TLSReloadInterval int    `yaml:"tls_reload_interval" env:"TLS_RELOAD_INTERVAL" env-default:"0"`
 }
 
 // Hashcash - Hashcash config structure
 type Hashcash struct {
-	Bits               int `yaml:"bits" env:"BITS" env-default:"5"`
-	ComputeMaxAttempts int `yaml:"compute_max_attempts"  env:"COMPUTE_MAX_ATTEMPTS" env-default:"100000000"`
-	TTL                int `yaml:"ttl"  env:"TTL" env-default:"60000"`
+	Bits               int     `yaml:"bits" env:"BITS" env-default:"5"`
+	Attempts           float64 `yaml:"attempts" env:"ATTEMPTS" env-default:"0"`
+	ComputeMaxAttempts int     `yaml:"compute_max_attempts"  env:"COMPUTE_MAX_ATTEMPTS" env-default:"100000000"`
+	TTL                int     `yaml:"ttl"  env:"TTL" env-default:"60000"`
+	MaxBits            int     `yaml:"max_bits" env:"MAX_BITS" env-default:"0"`
+	SolveBudget        int     `yaml:"solve_budget" env:"SOLVE_BUDGET" env-default:"0"`
+
+	TargetSolveTime int     `yaml:"target_solve_time" env:"TARGET_SOLVE_TIME" env-default:"0"`
+	HashRate        float64 `yaml:"hash_rate" env:"HASH_RATE" env-default:"0"`
+}
+
+// Trace - tracing config structure
+type Trace struct {
+	Exporter  string `yaml:"exporter" env:"EXPORTER" env-default:"none"`
+	Endpoint  string `yaml:"endpoint" env:"ENDPOINT" env-default:"localhost:4318"`
+	Insecure  bool   `yaml:"insecure" env:"INSECURE" env-default:"true"`
+	Propagate bool   `yaml:"propagate" env:"PROPAGATE" env-default:"false"`
 }
 
 // Parse - parse config from file by flag or from env or use default
 func Parse(flagName string) (config *Config, err error) {
-	var path string
+	return ParseFromPath(Path(flagName))
+}
 
+// Path - returns config file path passed by flag
+func Path(flagName string) (path string) {
 	flag.StringVar(&path, flagName, "", "")
 	flag.Parse()
 
+	return
+}
+
+// ParseFromPath - parse config from file or from env if path is empty
+// Can be called again to reload config
+func ParseFromPath(path string) (*Config, error) {
 	if path == "" {
 		return ParseFromEnv()
 	}
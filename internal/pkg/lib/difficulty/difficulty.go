@@ -0,0 +1,17 @@
+package difficulty
+
+import "context"
+
+type zeroBitsKey struct{}
+
+// NewContext - returns context with number of puzzle zero bits,
+// e.g. set by listener to override default difficulty
+func NewContext(ctx context.Context, zeroBits int) context.Context {
+	return context.WithValue(ctx, zeroBitsKey{}, zeroBits)
+}
+
+// FromContext - returns number of puzzle zero bits from context
+func FromContext(ctx context.Context) (zeroBits int, ok bool) {
+	zeroBits, ok = ctx.Value(zeroBitsKey{}).(int)
+	return
+}
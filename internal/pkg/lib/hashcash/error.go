@@ -8,4 +8,7 @@ var (
 	ErrHashLengthLessThanZeroBits   = errors.New("hash length cannot be less than zero bits")
 	ErrZeroBitsMustBeMoreThanZero   = errors.New("zero bits must be more than zero")
 	ErrComputingMaxAttemptsExceeded = errors.New("max attempts to compute correct hash exceeded")
+	ErrHashRateMustBeMoreThanZero   = errors.New("hash rate must be more than zero")
+	ErrSolveTimeMustBeMoreThanZero  = errors.New("solve time must be more than zero")
+	ErrIncorrectAttempts            = errors.New("attempts must be from 1 to 2^160")
 )
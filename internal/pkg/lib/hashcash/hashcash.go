@@ -1,10 +1,12 @@
 package hashcash
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/sha1"
 	"encoding/base64"
-	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"math"
 	"math/big"
@@ -13,14 +15,19 @@ import (
 	"time"
 )
 
+// MaxBits - max number of zero bits, it's number of hex digits in hash
+const MaxBits = sha1.Size * 2
+
 const (
-	dateLayout = "20060102150405"
-	zeroBit    = '0'
+	dateLayout       = "20060102150405"
+	zeroBit          = '0'
+	ctxCheckAttempts = 10000
+	targetExtension  = "target"
 )
 
 // New - returns new hashcash
 func New(bits int, resource string) (*Hashcash, error) {
-	rand, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt32))
+	rand, err := randBytes()
 	if err != nil {
 		return nil, err
 	}
@@ -33,19 +40,57 @@ func New(bits int, resource string) (*Hashcash, error) {
 		bits:     bits,
 		date:     time.Now().UTC().Truncate(time.Second),
 		resource: resource,
-		rand:     rand.Bytes(),
+		rand:     rand,
+	}, nil
+}
+
+// NewTarget - returns new hashcash solved in attempts expected attempts on average
+// Hash as big-endian number must be below target 2^160/attempts, so attempts isn't limited to powers of 16
+// Target is sent in extension, bits is number of zero bits every solution has
+func NewTarget(attempts float64, resource string) (*Hashcash, error) {
+	target, err := targetForAttempts(attempts)
+	if err != nil {
+		return nil, err
+	}
+
+	bits := 0
+	for bits < MaxBits && ExpectedAttempts(bits+1) <= attempts {
+		bits++
+	}
+
+	rand, err := randBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Hashcash{
+		bits:      bits,
+		date:      time.Now().UTC().Truncate(time.Second),
+		resource:  resource,
+		extension: targetExtension + "=" + new(big.Int).SetBytes(target).Text(16),
+		rand:      rand,
+		target:    target,
 	}, nil
 }
 
+func randBytes() ([]byte, error) {
+	rand, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt32))
+	if err != nil {
+		return nil, err
+	}
+	return rand.Bytes(), nil
+}
+
 // Hashcash - hashcash structure
 // Version 1
 type Hashcash struct {
 	bits      int       // number of zero bits in hashed code
 	date      time.Time // time that the message was sent
 	resource  string    // resource data string (IP address,  email address, etc)
-	extension string    // extension, ignored in this version
+	extension string    // extension, only target is supported in this version
 	rand      []byte    // random characters
 	counter   int       // computing counter
+	target    []byte    // big-endian target of hash, nil if hash is checked by zero bits
 }
 
 // Bits - returns number of zero bits
@@ -58,6 +103,26 @@ func (h *Hashcash) Counter() int {
 	return h.counter
 }
 
+// Resource - returns resource
+func (h *Hashcash) Resource() string {
+	return h.resource
+}
+
+// Date - returns time that the hashcash was created
+func (h *Hashcash) Date() time.Time {
+	return h.date
+}
+
+// Extension - returns extension
+func (h *Hashcash) Extension() string {
+	return h.extension
+}
+
+// Rand - returns random part encoded like in header
+func (h *Hashcash) Rand() string {
+	return base64.StdEncoding.EncodeToString(h.rand)
+}
+
 // EqualResource - check if input resource is equal with hashcash resource
 func (h *Hashcash) EqualResource(resource string) bool {
 	return h.resource == resource
@@ -68,13 +133,55 @@ func (h *Hashcash) IsActual(ttl time.Duration) bool {
 	return h.date.Add(ttl).After(time.Now().UTC())
 }
 
+// Difficulty - returns expected number of attempts to solve hashcash
+func (h *Hashcash) Difficulty() float64 {
+	if h.target == nil {
+		return ExpectedAttempts(h.bits)
+	}
+
+	attempts, _ := new(big.Float).Quo(new(big.Float).SetInt(maxTarget()), new(big.Float).SetInt(new(big.Int).SetBytes(h.target))).Float64()
+	return attempts
+}
+
+// IsSolved - check if hash is below target or has enough zero bits if hashcash has no target
+func (h *Hashcash) IsSolved() (bool, error) {
+	if h.target == nil {
+		return h.Header().IsHashCorrect(h.bits)
+	}
+
+	hash := sha1.Sum([]byte(h.Header()))
+	return bytes.Compare(hash[:], h.target) < 0, nil
+}
+
 // Compute - compute hash with enough zero bits in the begining
 // Increase counter if hash does't have enough zero bits in the begining
 func (h *Hashcash) Compute(maxAttempts int) error {
+	return h.ComputeContext(context.Background(), maxAttempts)
+}
+
+// ComputeContext - compute hash like Compute, but stop computing when context is done
+// Context is checked every ctxCheckAttempts attempts
+func (h *Hashcash) ComputeContext(ctx context.Context, maxAttempts int) error {
+	return h.ComputeProgress(ctx, maxAttempts, nil)
+}
+
+// ComputeProgress - compute hash like ComputeContext and report number of attempts
+// Progress is called every ctxCheckAttempts attempts before context is checked,
+// e.g. to let browser event loop run while computing in wasm
+func (h *Hashcash) ComputeProgress(ctx context.Context, maxAttempts int, progress func(attempts int)) error {
 	if maxAttempts > 0 {
 		h.counter = 0
 		for h.counter <= maxAttempts {
-			ok, err := h.Header().IsHashCorrect(h.bits)
+			if h.counter%ctxCheckAttempts == 0 {
+				if progress != nil && h.counter > 0 {
+					progress(h.counter)
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+
+			ok, err := h.IsSolved()
 			if err != nil {
 				return err
 			}
@@ -88,10 +195,126 @@ func (h *Hashcash) Compute(maxAttempts int) error {
 	return ErrComputingMaxAttemptsExceeded
 }
 
+// ExpectedAttempts - returns average number of attempts to compute hash with bits zero bits
+// Every zero bit is a hex digit of hash, so it's 16^bits
+func ExpectedAttempts(bits int) float64 {
+	return math.Pow(16, float64(bits))
+}
+
+// ValidateAttempts - check if target puzzle can be created with attempts, it's from 1 to 16^MaxBits
+func ValidateAttempts(attempts float64) error {
+	if math.IsNaN(attempts) || attempts < 1 || attempts > ExpectedAttempts(MaxBits) {
+		return ErrIncorrectAttempts
+	}
+	return nil
+}
+
+// targetForAttempts - returns big-endian target of sha1 hash, 2^160/attempts
+func targetForAttempts(attempts float64) ([]byte, error) {
+	if err := ValidateAttempts(attempts); err != nil {
+		return nil, err
+	}
+
+	target, _ := new(big.Float).Quo(new(big.Float).SetInt(maxTarget()), big.NewFloat(attempts)).Int(nil)
+	if target.Sign() <= 0 {
+		return nil, ErrIncorrectAttempts
+	}
+
+	// Any hash is below 2^160, but it doesn't fit in hash size
+	if target.BitLen() > sha1.Size*8 {
+		target.Sub(target, big.NewInt(1))
+	}
+
+	return target.FillBytes(make([]byte, sha1.Size)), nil
+}
+
+// parseTarget - returns target from hashcash extension, nil if extension has no target
+// Extension format - name1[=value1];name2[=value2]
+func parseTarget(extension string) ([]byte, error) {
+	for _, ext := range strings.Split(extension, ";") {
+		name, value, _ := strings.Cut(ext, "=")
+		if name != targetExtension {
+			continue
+		}
+
+		target, ok := new(big.Int).SetString(value, 16)
+		if !ok || target.Sign() <= 0 || target.BitLen() > sha1.Size*8 {
+			return nil, ErrIncorrectHeaderFormat
+		}
+		return target.FillBytes(make([]byte, sha1.Size)), nil
+	}
+
+	return nil, nil
+}
+
+func maxTarget() *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), sha1.Size*8)
+}
+
+// QuantileAttempts - returns number of attempts enough to compute hash with bits zero bits
+// with probability q, e.g. median for 0.5
+// Attempts are independent, so their number has geometric distribution
+func QuantileAttempts(bits int, q float64) float64 {
+	if q <= 0 {
+		return 0
+	}
+	if q >= 1 {
+		return math.Inf(1)
+	}
+
+	// Log1p keeps precision for tiny probability of success of many zero bits
+	p := 1 / ExpectedAttempts(bits)
+	return math.Log1p(-q) / math.Log1p(-p)
+}
+
+// BitsForSolveTime - returns max number of zero bits,
+// which median solve time at hash rate doesn't exceed target, but at least 1
+func BitsForSolveTime(rate float64, target time.Duration) (int, error) {
+	if rate <= 0 {
+		return 0, ErrHashRateMustBeMoreThanZero
+	}
+	if target <= 0 {
+		return 0, ErrSolveTimeMustBeMoreThanZero
+	}
+
+	bits := 1
+	for bits < MaxBits && QuantileAttempts(bits+1, 0.5)/rate <= target.Seconds() {
+		bits++
+	}
+
+	return bits, nil
+}
+
+// HashRate - measure local computing speed in attempts per second
+// Hashes are computed for duration, parent context cancellation stops measuring with error
+func HashRate(ctx context.Context, duration time.Duration) (float64, error) {
+	// Hash never has all digits zero in practice, so computing lasts until timeout
+	h, err := New(MaxBits, "hashrate")
+	if err != nil {
+		return 0, err
+	}
+
+	measureCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	start := time.Now()
+	if err := h.ComputeContext(measureCtx, math.MaxInt); err != nil && ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	return float64(h.counter) / time.Since(start).Seconds(), nil
+}
+
 // Key - returns string presentation of hashcash without counter
 // Key is using to match original hashcash with solved hashcash
+// Random part may have any length, e.g. it's shorter if generated number has leading zero bytes
+// Target is a part of key, so it can't be raised by solver
 func (h *Hashcash) Key() string {
-	return fmt.Sprintf("%d:%d:%s:%d", h.bits, h.date.Unix(), h.resource, binary.BigEndian.Uint32(h.rand))
+	key := fmt.Sprintf("%d:%d:%s:%s", h.bits, h.date.Unix(), h.resource, base64.StdEncoding.EncodeToString(h.rand))
+	if h.target != nil {
+		key += ":" + hex.EncodeToString(h.target)
+	}
+	return key
 }
 
 // Header - returns string presentation of hashcash to share it
@@ -141,6 +364,11 @@ func ParseHeader(header string) (hashcash *Hashcash, err error) {
 	hashcash.resource = parts[3]
 	hashcash.extension = parts[4]
 
+	hashcash.target, err = parseTarget(parts[4])
+	if err != nil {
+		return nil, err
+	}
+
 	hashcash.rand, err = base64.StdEncoding.DecodeString(parts[5])
 	if err != nil {
 		return nil, ErrIncorrectHeaderFormat
@@ -187,6 +415,25 @@ func (header Header) IsHashCorrect(bits int) (ok bool, err error) {
 	return
 }
 
+// Hash - returns hex encoded sha1 hash of header
+func (header Header) Hash() (string, error) {
+	return header.sha1()
+}
+
+// ZeroBits - returns number of zero bits in the begining of header hash
+func (header Header) ZeroBits() (int, error) {
+	hash, err := header.sha1()
+	if err != nil {
+		return 0, err
+	}
+
+	bits := 0
+	for bits < len(hash) && hash[bits] == zeroBit {
+		bits++
+	}
+	return bits, nil
+}
+
 func (header Header) sha1() (hash string, err error) {
 	hasher := sha1.New()
 	if _, err = hasher.Write([]byte(header)); err != nil {
@@ -1,7 +1,11 @@
 package hashcash
 
 import (
+	"context"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -18,6 +22,12 @@ func Test_New(t *testing.T) {
 		parsed.counter++
 		require.Equal(t, original.Key(), parsed.Key())
 	})
+
+	t.Run("key of short random part ok", func(t *testing.T) {
+		parsed, err := ParseHeader("1:5:20231102192537:resource::AQI=:MA==")
+		require.NoError(t, err)
+		require.Equal(t, "5:1698953137:resource:AQI=", parsed.Key())
+	})
 }
 
 func Test_Compute(t *testing.T) {
@@ -41,4 +51,224 @@ func Test_Compute(t *testing.T) {
 		err = hashcash.Compute(279189)
 		require.EqualError(t, ErrComputingMaxAttemptsExceeded, err.Error())
 	})
+	t.Run("compute context canceled", func(t *testing.T) {
+		header := "1:5:20231102192537:resource::Cxphfw==:MA=="
+
+		hashcash, err := ParseHeader(header)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err = hashcash.ComputeContext(ctx, 1000000)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 0, hashcash.counter)
+	})
+
+	t.Run("compute progress ok", func(t *testing.T) {
+		header := "1:5:20231102192537:resource::Cxphfw==:MA=="
+
+		hashcash, err := ParseHeader(header)
+		require.NoError(t, err)
+
+		var reported []int
+		err = hashcash.ComputeProgress(context.Background(), 1000000, func(attempts int) {
+			reported = append(reported, attempts)
+		})
+		require.NoError(t, err)
+		require.Len(t, reported, 27)
+		require.Equal(t, 10000, reported[0])
+		require.Equal(t, 270000, reported[26])
+	})
+
+	t.Run("compute progress canceled", func(t *testing.T) {
+		header := "1:5:20231102192537:resource::Cxphfw==:MA=="
+
+		hashcash, err := ParseHeader(header)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err = hashcash.ComputeProgress(ctx, 1000000, func(attempts int) {
+			if attempts == 20000 {
+				cancel()
+			}
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 20000, hashcash.counter)
+	})
+}
+
+func Test_Header(t *testing.T) {
+	t.Run("fields ok", func(t *testing.T) {
+		hashcash, err := ParseHeader("1:5:20231102192537:resource:ext:Cxphfw==:Mjc5MTkw")
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2023, 11, 2, 19, 25, 37, 0, time.UTC), hashcash.Date())
+		require.Equal(t, "ext", hashcash.Extension())
+		require.Equal(t, "Cxphfw==", hashcash.Rand())
+	})
+
+	t.Run("hash and zero bits of solved header", func(t *testing.T) {
+		header := Header("1:5:20231102192537:resource::Cxphfw==:Mjc5MTkw")
+
+		hash, err := header.Hash()
+		require.NoError(t, err)
+		require.Equal(t, "00000da73bd9e2fee5cc39551db63af147e2325c", hash)
+
+		bits, err := header.ZeroBits()
+		require.NoError(t, err)
+		require.Equal(t, 5, bits)
+	})
+
+	t.Run("zero bits of unsolved header", func(t *testing.T) {
+		bits, err := Header("1:5:20231102192537:resource::Cxphfw==:MA==").ZeroBits()
+		require.NoError(t, err)
+		require.Equal(t, 0, bits)
+	})
+}
+
+func Test_NewTarget(t *testing.T) {
+	t.Run("target of power of 16 is equal to zero bits", func(t *testing.T) {
+		h, err := NewTarget(16, "resource")
+		require.NoError(t, err)
+		require.Equal(t, 1, h.Bits())
+		require.Equal(t, "target=1"+strings.Repeat("0", 39), h.Extension())
+		require.Equal(t, float64(16), h.Difficulty())
+	})
+
+	t.Run("fractional difficulty", func(t *testing.T) {
+		h, err := NewTarget(100000, "resource")
+		require.NoError(t, err)
+		require.Equal(t, 4, h.Bits())
+		require.InEpsilon(t, float64(100000), h.Difficulty(), 1e-9)
+
+		parsed, err := ParseHeader(string(h.Header()))
+		require.NoError(t, err)
+		require.Equal(t, h, parsed)
+		require.Equal(t, h.Key(), parsed.Key())
+
+		require.NoError(t, parsed.Compute(100000000))
+		ok, err := parsed.IsSolved()
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// Solution below target has at least bits zero bits
+		ok, err = parsed.Header().IsHashCorrect(parsed.Bits())
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("min difficulty", func(t *testing.T) {
+		h, err := NewTarget(1, "resource")
+		require.NoError(t, err)
+		require.Equal(t, 0, h.Bits())
+		require.Equal(t, strings.Repeat("f", 40), strings.TrimPrefix(h.Extension(), "target="))
+
+		require.NoError(t, h.Compute(10))
+		require.Equal(t, 0, h.Counter())
+	})
+
+	t.Run("incorrect attempts", func(t *testing.T) {
+		for _, attempts := range []float64{0, 0.5, -1, math.NaN(), math.Inf(1), ExpectedAttempts(MaxBits) * 2} {
+			_, err := NewTarget(attempts, "resource")
+			require.ErrorIs(t, err, ErrIncorrectAttempts, attempts)
+		}
+	})
+
+	t.Run("validate attempts", func(t *testing.T) {
+		for _, attempts := range []float64{1, 1.5, 100000, ExpectedAttempts(MaxBits)} {
+			require.NoError(t, ValidateAttempts(attempts), attempts)
+		}
+		for _, attempts := range []float64{0, 0.5, -1, 1e60, math.NaN(), math.Inf(1)} {
+			require.ErrorIs(t, ValidateAttempts(attempts), ErrIncorrectAttempts, attempts)
+		}
+	})
+
+	t.Run("raised target doesn't match key", func(t *testing.T) {
+		h, err := NewTarget(100000, "resource")
+		require.NoError(t, err)
+
+		raised := strings.Replace(string(h.Header()), h.Extension(), "target="+strings.Repeat("f", 40), 1)
+		parsed, err := ParseHeader(raised)
+		require.NoError(t, err)
+		require.NotEqual(t, h.Key(), parsed.Key())
+
+		stripped := strings.Replace(string(h.Header()), h.Extension(), "", 1)
+		parsed, err = ParseHeader(stripped)
+		require.NoError(t, err)
+		require.NotEqual(t, h.Key(), parsed.Key())
+	})
+
+	t.Run("incorrect target", func(t *testing.T) {
+		for _, ext := range []string{"target=", "target=xyz", "target=0", "target=1" + strings.Repeat("0", 40)} {
+			_, err := ParseHeader("1:5:20231102192537:resource:" + ext + ":Cxphfw==:MA==")
+			require.ErrorIs(t, err, ErrIncorrectHeaderFormat, ext)
+		}
+	})
+
+	t.Run("other extensions are kept", func(t *testing.T) {
+		h, err := ParseHeader("1:1:20231102192537:resource:a=b;target=f" + strings.Repeat("0", 39) + ";c:Cxphfw==:MA==")
+		require.NoError(t, err)
+		require.Equal(t, "a=b;target=f"+strings.Repeat("0", 39)+";c", h.Extension())
+		require.InEpsilon(t, 16.0/15, h.Difficulty(), 1e-9)
+	})
+}
+
+func Test_HashRate(t *testing.T) {
+	t.Run("expected attempts", func(t *testing.T) {
+		require.Equal(t, float64(1), ExpectedAttempts(0))
+		require.Equal(t, float64(16), ExpectedAttempts(1))
+		require.Equal(t, float64(1048576), ExpectedAttempts(5))
+	})
+
+	t.Run("quantile attempts", func(t *testing.T) {
+		require.Equal(t, float64(0), QuantileAttempts(5, 0))
+		require.True(t, math.IsInf(QuantileAttempts(5, 1), 1))
+		require.InDelta(t, 10.74, QuantileAttempts(1, 0.5), 0.01)
+
+		// Median of many zero bits is ln2 of mean
+		require.InEpsilon(t, math.Ln2*ExpectedAttempts(5), QuantileAttempts(5, 0.5), 1e-5)
+		require.InEpsilon(t, math.Ln2*ExpectedAttempts(MaxBits), QuantileAttempts(MaxBits, 0.5), 1e-9)
+		require.Less(t, QuantileAttempts(5, 0.5), QuantileAttempts(5, 0.9))
+	})
+
+	t.Run("bits for solve time", func(t *testing.T) {
+		// Median attempts: 5 bits - 726817, 6 bits - 11629080
+		bits, err := BitsForSolveTime(1000000, time.Second)
+		require.NoError(t, err)
+		require.Equal(t, 5, bits)
+
+		bits, err = BitsForSolveTime(1000000, 12*time.Second)
+		require.NoError(t, err)
+		require.Equal(t, 6, bits)
+
+		bits, err = BitsForSolveTime(1, time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, 1, bits)
+
+		bits, err = BitsForSolveTime(math.MaxFloat64, time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, MaxBits, bits)
+
+		_, err = BitsForSolveTime(0, time.Second)
+		require.ErrorIs(t, err, ErrHashRateMustBeMoreThanZero)
+
+		_, err = BitsForSolveTime(1000000, 0)
+		require.ErrorIs(t, err, ErrSolveTimeMustBeMoreThanZero)
+	})
+
+	t.Run("hash rate ok", func(t *testing.T) {
+		rate, err := HashRate(context.Background(), 50*time.Millisecond)
+		require.NoError(t, err)
+		require.Greater(t, rate, float64(0))
+	})
+
+	t.Run("hash rate canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := HashRate(ctx, time.Second)
+		require.ErrorIs(t, err, context.Canceled)
+	})
 }
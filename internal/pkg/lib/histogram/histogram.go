@@ -0,0 +1,117 @@
+package histogram
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBounds - upper bounds of buckets in 1-2-5 series from 1ms to 1m
+var DefaultBounds = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second,
+	10 * time.Second, 20 * time.Second, 30 * time.Second, time.Minute,
+}
+
+// New - create new histogram with buckets of ascending upper bounds
+// DefaultBounds are used if bounds are empty, values above the last bound get to overflow bucket
+func New(bounds []time.Duration) *Histogram {
+	if len(bounds) == 0 {
+		bounds = DefaultBounds
+	}
+
+	return &Histogram{
+		bounds: bounds,
+	}
+}
+
+// Histogram - latency histogram, it's safe for concurrent use
+// Values are kept to calculate exact percentiles
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []time.Duration
+	values []time.Duration
+}
+
+// Record - add value to histogram
+func (h *Histogram) Record(value time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.values = append(h.values, value)
+}
+
+// Snapshot - returns summary of recorded values
+func (h *Histogram) Snapshot() Snapshot {
+	h.mu.Lock()
+	values := make([]time.Duration, len(h.values))
+	copy(values, h.values)
+	h.mu.Unlock()
+
+	s := Snapshot{
+		Count:   len(values),
+		Buckets: make([]Bucket, len(h.bounds)+1),
+	}
+	for i, bound := range h.bounds {
+		s.Buckets[i].UpperBound = bound
+	}
+	s.Buckets[len(h.bounds)].UpperBound = math.MaxInt64
+
+	if len(values) == 0 {
+		return s
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var sum time.Duration
+	for _, value := range values {
+		sum += value
+
+		i := sort.Search(len(h.bounds), func(i int) bool { return value <= h.bounds[i] })
+		s.Buckets[i].Count++
+	}
+
+	s.Min = values[0]
+	s.Max = values[len(values)-1]
+	s.Mean = sum / time.Duration(len(values))
+	s.P50 = percentile(values, 50)
+	s.P90 = percentile(values, 90)
+	s.P99 = percentile(values, 99)
+
+	return s
+}
+
+// Snapshot - summary of histogram values
+type Snapshot struct {
+	Count   int
+	Min     time.Duration
+	Max     time.Duration
+	Mean    time.Duration
+	P50     time.Duration
+	P90     time.Duration
+	P99     time.Duration
+	Buckets []Bucket
+}
+
+// Bucket - number of values less or equal than upper bound and greater than previous bound
+type Bucket struct {
+	UpperBound time.Duration
+	Count      int
+}
+
+// Milliseconds - returns duration in milliseconds with fraction, e.g. to report snapshot values
+func Milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// percentile - returns nearest-rank percentile of sorted values
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,42 @@
+package histogram
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Histogram(t *testing.T) {
+	t.Run("snapshot ok", func(t *testing.T) {
+		h := New([]time.Duration{10 * time.Millisecond, 100 * time.Millisecond})
+		for i := 1; i <= 100; i++ {
+			h.Record(time.Duration(i) * 2 * time.Millisecond)
+		}
+
+		s := h.Snapshot()
+		require.Equal(t, 100, s.Count)
+		require.Equal(t, 2*time.Millisecond, s.Min)
+		require.Equal(t, 200*time.Millisecond, s.Max)
+		require.Equal(t, 101*time.Millisecond, s.Mean)
+		require.Equal(t, 100*time.Millisecond, s.P50)
+		require.Equal(t, 180*time.Millisecond, s.P90)
+		require.Equal(t, 198*time.Millisecond, s.P99)
+		require.Equal(t, []Bucket{
+			{UpperBound: 10 * time.Millisecond, Count: 5},
+			{UpperBound: 100 * time.Millisecond, Count: 45},
+			{UpperBound: math.MaxInt64, Count: 50},
+		}, s.Buckets)
+	})
+
+	t.Run("empty snapshot", func(t *testing.T) {
+		s := New(nil).Snapshot()
+		require.Equal(t, 0, s.Count)
+		require.Len(t, s.Buckets, len(DefaultBounds)+1)
+	})
+}
+
+func Test_Milliseconds(t *testing.T) {
+	require.Equal(t, 1.5, Milliseconds(1500*time.Microsecond))
+}
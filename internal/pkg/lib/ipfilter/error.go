@@ -0,0 +1,10 @@
+package ipfilter
+
+import "errors"
+
+// Errors
+var (
+	ErrDenied     = errors.New("ip is denied")
+	ErrNotAllowed = errors.New("ip is not allowed")
+	ErrBanned     = errors.New("ip is temporarily banned")
+)
@@ -0,0 +1,7 @@
+package ipfilter
+
+// Logger - logger interface
+type Logger interface {
+	Info(msg string, args ...any)
+	Debug(msg string, args ...any)
+}
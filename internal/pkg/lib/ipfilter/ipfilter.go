@@ -0,0 +1,151 @@
+package ipfilter
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cache"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cidr"
+)
+
+// Opts - options to create new filter instance
+// Allow - if not empty only ips from these networks are allowed
+// Deny - ips from these networks are denied, deny takes precedence over allow
+// BanThreshold - number of failures within BanWindow to ban ip for BanDuration, uses if value > 0
+type Opts struct {
+	Allow        []string
+	Deny         []string
+	BanThreshold int
+	BanWindow    time.Duration
+	BanDuration  time.Duration
+	Logger       Logger
+}
+
+// New - create new ip filter instance
+func New(ctx context.Context, opts Opts) (*Filter, error) {
+	f := &Filter{
+		banThreshold: opts.BanThreshold,
+		banWindow:    opts.BanWindow,
+		banDuration:  opts.BanDuration,
+		logger:       opts.Logger,
+	}
+
+	if err := f.Update(opts.Allow, opts.Deny); err != nil {
+		return nil, err
+	}
+
+	if f.banThreshold > 0 {
+		f.failures = cache.New[string, failures](ctx, cache.Opts{
+			CleanInterval: opts.BanWindow,
+			Logger:        opts.Logger,
+		})
+		f.bans = cache.New[string, struct{}](ctx, cache.Opts{
+			CleanInterval: opts.BanDuration,
+			Logger:        opts.Logger,
+		})
+	}
+
+	return f, nil
+}
+
+// Filter - ip filter with allow and deny lists and temporary bans
+type Filter struct {
+	mu    sync.RWMutex
+	allow cidr.List
+	deny  cidr.List
+
+	banThreshold int
+	banWindow    time.Duration
+	banDuration  time.Duration
+	failuresMu   sync.Mutex
+	failures     *cache.Cache[string, failures]
+	bans         *cache.Cache[string, struct{}]
+
+	logger Logger
+}
+
+// Update - replace allow and deny lists
+// Lists are kept unchanged if any of them is incorrect
+func (f *Filter) Update(allow []string, deny []string) error {
+	allowList, err := cidr.ParseList(allow)
+	if err != nil {
+		return err
+	}
+	denyList, err := cidr.ParseList(deny)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.allow = allowList
+	f.deny = denyList
+
+	return nil
+}
+
+// Check - check if ip is allowed to connect
+// Ip without address (nil), e.g. unix socket client, is always allowed
+func (f *Filter) Check(ip net.IP) error {
+	if ip == nil {
+		return nil
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.deny.Contains(ip) {
+		return ErrDenied
+	}
+	if len(f.allow) > 0 && !f.allow.Contains(ip) {
+		return ErrNotAllowed
+	}
+	if f.banThreshold > 0 {
+		if _, ok := f.bans.Get(ip.String()); ok {
+			return ErrBanned
+		}
+	}
+
+	return nil
+}
+
+// ReportFailure - count failure of ip and ban it if failures threshold is reached
+// Returns true if ip has been banned
+func (f *Filter) ReportFailure(ip net.IP) (banned bool) {
+	const op = "ipfilter.ReportFailure"
+
+	if ip == nil || f.banThreshold <= 0 {
+		return false
+	}
+
+	f.failuresMu.Lock()
+	defer f.failuresMu.Unlock()
+
+	key := ip.String()
+
+	// Window starts from the first failure and isn't prolonged by next ones
+	value, ok := f.failures.Get(key)
+	if !ok {
+		value.exp = time.Now().Add(f.banWindow)
+	}
+	value.count++
+
+	if value.count < f.banThreshold {
+		f.failures.AddWithExp(key, value, value.exp)
+		return false
+	}
+
+	f.failures.Delete(key)
+	f.bans.AddWithExp(key, struct{}{}, time.Now().Add(f.banDuration))
+	f.logger.Info("ip banned", "op", op, "ip", key, "failures", value.count, "duration", f.banDuration)
+
+	return true
+}
+
+type failures struct {
+	count int
+	exp   time.Time
+}
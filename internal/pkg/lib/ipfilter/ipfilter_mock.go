@@ -0,0 +1,13 @@
+package ipfilter
+
+type mockLogger struct {
+	banned int
+}
+
+func (l *mockLogger) Info(msg string, args ...any) {
+	if msg == "ip banned" {
+		l.banned++
+	}
+}
+
+func (l *mockLogger) Debug(msg string, args ...any) {}
@@ -0,0 +1,97 @@
+package ipfilter
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Filter(t *testing.T) {
+	t.Run("allow and deny ok", func(t *testing.T) {
+		f, err := New(context.Background(), Opts{
+			Allow:  []string{"10.0.0.0/8"},
+			Deny:   []string{"10.0.0.1"},
+			Logger: &mockLogger{},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, f.Check(net.ParseIP("10.0.0.2")))
+		require.EqualError(t, ErrDenied, f.Check(net.ParseIP("10.0.0.1")).Error())
+		require.EqualError(t, ErrNotAllowed, f.Check(net.ParseIP("192.168.0.1")).Error())
+		require.NoError(t, f.Check(nil))
+	})
+
+	t.Run("update ok", func(t *testing.T) {
+		f, err := New(context.Background(), Opts{Logger: &mockLogger{}})
+		require.NoError(t, err)
+		require.NoError(t, f.Check(net.ParseIP("192.168.0.1")))
+
+		require.NoError(t, f.Update(nil, []string{"192.168.0.0/16"}))
+		require.EqualError(t, ErrDenied, f.Check(net.ParseIP("192.168.0.1")).Error())
+
+		// Incorrect lists must not replace actual ones
+		require.Error(t, f.Update(nil, []string{"incorrect"}))
+		require.EqualError(t, ErrDenied, f.Check(net.ParseIP("192.168.0.1")).Error())
+
+		require.NoError(t, f.Update(nil, nil))
+		require.NoError(t, f.Check(net.ParseIP("192.168.0.1")))
+	})
+
+	t.Run("ban ok", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		logger := &mockLogger{}
+		f, err := New(ctx, Opts{
+			BanThreshold: 3,
+			BanWindow:    time.Second,
+			BanDuration:  100 * time.Millisecond,
+			Logger:       logger,
+		})
+		require.NoError(t, err)
+
+		ip := net.ParseIP("192.168.0.1")
+		require.False(t, f.ReportFailure(ip))
+		require.False(t, f.ReportFailure(ip))
+		require.NoError(t, f.Check(ip))
+
+		require.True(t, f.ReportFailure(ip))
+		require.EqualError(t, ErrBanned, f.Check(ip).Error())
+		require.NoError(t, f.Check(net.ParseIP("192.168.0.2")))
+		require.Equal(t, 1, logger.banned)
+
+		// Ban must be expired
+		time.Sleep(150 * time.Millisecond)
+		require.NoError(t, f.Check(ip))
+	})
+
+	t.Run("failures outside window are forgotten", func(t *testing.T) {
+		f, err := New(context.Background(), Opts{
+			BanThreshold: 2,
+			BanWindow:    50 * time.Millisecond,
+			BanDuration:  time.Second,
+			Logger:       &mockLogger{},
+		})
+		require.NoError(t, err)
+
+		ip := net.ParseIP("192.168.0.1")
+		require.False(t, f.ReportFailure(ip))
+		time.Sleep(100 * time.Millisecond)
+		require.False(t, f.ReportFailure(ip))
+		require.NoError(t, f.Check(ip))
+	})
+
+	t.Run("ban disabled", func(t *testing.T) {
+		f, err := New(context.Background(), Opts{Logger: &mockLogger{}})
+		require.NoError(t, err)
+
+		ip := net.ParseIP("192.168.0.1")
+		for i := 0; i < 10; i++ {
+			require.False(t, f.ReportFailure(ip))
+		}
+		require.NoError(t, f.Check(ip))
+	})
+}
@@ -0,0 +1,9 @@
+package limit
+
+import "errors"
+
+// Errors
+var (
+	ErrLimitExceeded    = errors.New("limit exceeded")
+	ErrKeyLimitExceeded = errors.New("limit per key exceeded")
+)
@@ -0,0 +1,101 @@
+package limit
+
+import (
+	"context"
+	"sync"
+)
+
+// Opts - options to create new limiter instance
+// Max and MaxPerKey are ignored if value <= 0
+type Opts struct {
+	Max       int
+	MaxPerKey int
+}
+
+// New - create new concurrency limiter instance
+func New(opts Opts) *Limiter {
+	return &Limiter{
+		max:       opts.Max,
+		maxPerKey: opts.MaxPerKey,
+		perKey:    make(map[string]int),
+		released:  make(chan struct{}),
+	}
+}
+
+// Limiter - limits number of concurrently held slots in total and per key
+type Limiter struct {
+	max       int
+	maxPerKey int
+
+	mu       sync.Mutex
+	total    int
+	perKey   map[string]int
+	released chan struct{}
+}
+
+// TryAcquire - take slot for key without waiting
+func (l *Limiter) TryAcquire(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.acquire(key)
+}
+
+// Acquire - take slot for key waiting until some slot is released or context is done
+// Returns limit error if context is done before slot was taken
+func (l *Limiter) Acquire(ctx context.Context, key string) error {
+	for {
+		l.mu.Lock()
+		err := l.acquire(key)
+		released := l.released
+		l.mu.Unlock()
+
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-released:
+		}
+	}
+}
+
+// Release - release slot taken for key
+func (l *Limiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+	if l.perKey[key] <= 1 {
+		delete(l.perKey, key)
+	} else {
+		l.perKey[key]--
+	}
+
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// Active - returns number of taken slots
+func (l *Limiter) Active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.total
+}
+
+func (l *Limiter) acquire(key string) error {
+	if l.max > 0 && l.total >= l.max {
+		return ErrLimitExceeded
+	}
+	if l.maxPerKey > 0 && l.perKey[key] >= l.maxPerKey {
+		return ErrKeyLimitExceeded
+	}
+
+	l.total++
+	l.perKey[key]++
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package limit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Limiter(t *testing.T) {
+	t.Run("try acquire ok", func(t *testing.T) {
+		l := New(Opts{Max: 3, MaxPerKey: 2})
+
+		require.NoError(t, l.TryAcquire("a"))
+		require.NoError(t, l.TryAcquire("a"))
+		require.EqualError(t, ErrKeyLimitExceeded, l.TryAcquire("a").Error())
+
+		require.NoError(t, l.TryAcquire("b"))
+		require.EqualError(t, ErrLimitExceeded, l.TryAcquire("c").Error())
+		require.Equal(t, 3, l.Active())
+
+		l.Release("a")
+		require.NoError(t, l.TryAcquire("c"))
+		require.Equal(t, 3, l.Active())
+
+		l.Release("a")
+		l.Release("b")
+		l.Release("c")
+		require.Equal(t, 0, l.Active())
+		require.Empty(t, l.perKey)
+	})
+
+	t.Run("unlimited ok", func(t *testing.T) {
+		l := New(Opts{})
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, l.TryAcquire("a"))
+		}
+		require.Equal(t, 100, l.Active())
+	})
+
+	t.Run("acquire waits for release", func(t *testing.T) {
+		l := New(Opts{Max: 1})
+		require.NoError(t, l.TryAcquire("a"))
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			l.Release("a")
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		require.NoError(t, l.Acquire(ctx, "b"))
+		require.Equal(t, 1, l.Active())
+	})
+
+	t.Run("acquire timeout exceeded", func(t *testing.T) {
+		l := New(Opts{MaxPerKey: 1})
+		require.NoError(t, l.TryAcquire("a"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := l.Acquire(ctx, "a")
+		require.EqualError(t, ErrKeyLimitExceeded, err.Error())
+		require.Equal(t, 1, l.Active())
+	})
+}
@@ -5,4 +5,5 @@ import "errors"
 // Errors
 var (
 	ErrIncorrectMessageFormat = errors.New("incorrect message format")
+	ErrMessageTooLarge        = errors.New("message too large")
 )
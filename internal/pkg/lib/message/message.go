@@ -1,7 +1,10 @@
 package message
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"net"
 	"strings"
 )
 
@@ -23,6 +26,12 @@ const (
 
 	// CommandResponseResource - using when server sends resource to client
 	CommandResponseResource
+
+	// CommandRequestHandshake - using when client sends credentials to server
+	CommandRequestHandshake
+
+	// CommandResponseHandshake - using when server accepts client credentials
+	CommandResponseHandshake
 )
 
 const (
@@ -31,47 +40,144 @@ const (
 
 	// DelimiterCommand - sign to devide command and payload in message
 	DelimiterCommand = ':'
+
+	// DelimiterTrace - sign to devide command and optional trace context in message
+	DelimiterTrace = ';'
 )
 
+// MaxMessageSize - max size of message including delimiter
+const MaxMessageSize = 64 * 1024
+
+// ReadMessage - read raw message including delimiter
+// Returns read data and error if reader fails before delimiter or message exceeds MaxMessageSize.
+// Reads byte by byte, so data following the message stays unread, e.g. proxied stream.
+// Use Conn or other io.ByteReader to read bytes from buffer instead of calling Read for every byte
+func ReadMessage(r io.Reader) (string, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = byteReader{r: r}
+	}
+
+	var msg strings.Builder
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return msg.String(), err
+		}
+		if msg.Len() >= MaxMessageSize {
+			return msg.String(), ErrMessageTooLarge
+		}
+
+		msg.WriteByte(b)
+		if b == DelimiterMessage {
+			return msg.String(), nil
+		}
+	}
+}
+
+// byteReader - reads reader byte by byte
+type byteReader struct {
+	r io.Reader
+}
+
+func (br byteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	for {
+		n, err := br.r.Read(b[:])
+		if n > 0 {
+			return b[0], nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// NewConn - wrap connection to read messages from buffer
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		Conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+}
+
+// Conn - connection reading through buffer
+// Data following messages is read from buffer first, so connection can be proxied after protocol
+type Conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read - read buffered data and then connection
+func (c *Conn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// ReadByte - read byte from buffer
+func (c *Conn) ReadByte() (byte, error) {
+	return c.reader.ReadByte()
+}
+
+// CloseWrite - close writing side of connection if it supports half-close, otherwise close connection
+func (c *Conn) CloseWrite() error {
+	if conn, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return conn.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
 // ParseMessage - parse message from string
-// string has "command:payload" format where command could be 0-4
+// string has "command[;trace]:payload" format where command could be 0-6
 func ParseMessage(msg string) (m Message, err error) {
 	msg = strings.TrimSpace(msg)
 
-	if len(msg) < 2 {
+	head, payload, ok := strings.Cut(msg, string(DelimiterCommand))
+	if !ok {
 		return m, ErrIncorrectMessageFormat
 	}
 
-	switch msg[:2] {
-	case fmt.Sprintf("0%c", DelimiterCommand):
+	command, trace, hasTrace := strings.Cut(head, string(DelimiterTrace))
+	if hasTrace && trace == "" {
+		return m, ErrIncorrectMessageFormat
+	}
+
+	switch command {
+	case "0":
 		m.Command = CommandError
-	case fmt.Sprintf("1%c", DelimiterCommand):
+	case "1":
 		m.Command = CommandRequestPuzzle
-	case fmt.Sprintf("2%c", DelimiterCommand):
+	case "2":
 		m.Command = CommandResponsePuzzle
-	case fmt.Sprintf("3%c", DelimiterCommand):
+	case "3":
 		m.Command = CommandRequestResource
-	case fmt.Sprintf("4%c", DelimiterCommand):
+	case "4":
 		m.Command = CommandResponseResource
+	case "5":
+		m.Command = CommandRequestHandshake
+	case "6":
+		m.Command = CommandResponseHandshake
 	default:
 		return m, ErrIncorrectMessageFormat
 	}
 
-	if len(msg) > 2 {
-		m.Payload = strings.TrimSpace(msg[2:])
-	}
+	m.Trace = trace
+	m.Payload = strings.TrimSpace(payload)
 
 	return
 }
 
-// Message - message with command and payload
+// Message - message with command, payload and optional trace context
 type Message struct {
 	Command Command
 	Payload string
+	Trace   string
 }
 
 // String - format message as string
 func (m Message) String() string {
+	if m.Trace != "" {
+		return fmt.Sprintf("%d%c%s%c%s%c", m.Command, DelimiterTrace, m.Trace, DelimiterCommand, m.Payload, DelimiterMessage)
+	}
 	return fmt.Sprintf("%d%c%s%c", m.Command, DelimiterCommand, m.Payload, DelimiterMessage)
 }
 
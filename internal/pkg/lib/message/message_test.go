@@ -1,6 +1,9 @@
 package message
 
 import (
+	"io"
+	"net"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -32,15 +35,110 @@ func Test_Message(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, Message{Command: CommandResponseResource, Payload: "resource"}, act)
 		require.Equal(t, "4:resource\n", act.String())
+
+		act, err = ParseMessage("5:api-key")
+		require.NoError(t, err)
+		require.Equal(t, Message{Command: CommandRequestHandshake, Payload: "api-key"}, act)
+		require.Equal(t, "5:api-key\n", act.String())
+
+		act, err = ParseMessage("6:")
+		require.NoError(t, err)
+		require.Equal(t, Message{Command: CommandResponseHandshake, Payload: ""}, act)
+		require.Equal(t, "6:\n", act.String())
+	})
+
+	t.Run("Parse message with trace ok", func(t *testing.T) {
+		trace := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+		act, err := ParseMessage("3;" + trace + ":1:5:20231102192537:resource::Cxphfw==:MA==")
+		require.NoError(t, err)
+		require.Equal(t, Message{Command: CommandRequestResource, Payload: "1:5:20231102192537:resource::Cxphfw==:MA==", Trace: trace}, act)
+		require.Equal(t, "3;"+trace+":1:5:20231102192537:resource::Cxphfw==:MA==\n", act.String())
+
+		act, err = ParseMessage("1;" + trace + ":")
+		require.NoError(t, err)
+		require.Equal(t, Message{Command: CommandRequestPuzzle, Payload: "", Trace: trace}, act)
+		require.Equal(t, "1;"+trace+":\n", act.String())
 	})
 
 	t.Run("Parse message failed", func(t *testing.T) {
-		act, err := ParseMessage("5:unknown")
+		act, err := ParseMessage("7:unknown")
 		require.EqualError(t, ErrIncorrectMessageFormat, err.Error())
 		require.Equal(t, Message{}, act)
 
 		act, err = ParseMessage("incorrect message")
 		require.EqualError(t, ErrIncorrectMessageFormat, err.Error())
 		require.Equal(t, Message{}, act)
+
+		act, err = ParseMessage("1;:")
+		require.EqualError(t, ErrIncorrectMessageFormat, err.Error())
+		require.Equal(t, Message{}, act)
+	})
+}
+
+func Test_ReadMessage(t *testing.T) {
+	t.Run("Read message ok", func(t *testing.T) {
+		r := strings.NewReader("4:resource\nproxied data")
+
+		act, err := ReadMessage(r)
+		require.NoError(t, err)
+		require.Equal(t, "4:resource\n", act)
+
+		rest, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "proxied data", string(rest))
 	})
+
+	t.Run("Read message of connection ok", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer client.Close()
+
+		go func() {
+			client.Write([]byte("3:solution\n4:resource\nproxied data"))
+			client.Close()
+		}()
+
+		conn := NewConn(server)
+		defer conn.Close()
+
+		act, err := ReadMessage(conn)
+		require.NoError(t, err)
+		require.Equal(t, "3:solution\n", act)
+
+		act, err = ReadMessage(conn)
+		require.NoError(t, err)
+		require.Equal(t, "4:resource\n", act)
+
+		rest, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		require.Equal(t, "proxied data", string(rest))
+	})
+
+	t.Run("Read oversized message", func(t *testing.T) {
+		r := io.MultiReader(strings.NewReader("3:"), infiniteReader{})
+
+		act, err := ReadMessage(r)
+		require.ErrorIs(t, err, ErrMessageTooLarge)
+		require.Len(t, act, MaxMessageSize)
+
+		act, err = ReadMessage(strings.NewReader("3:" + strings.Repeat("a", MaxMessageSize-3) + "\n"))
+		require.NoError(t, err)
+		require.Len(t, act, MaxMessageSize)
+	})
+
+	t.Run("Read message without delimiter", func(t *testing.T) {
+		act, err := ReadMessage(strings.NewReader("4:resource"))
+		require.ErrorIs(t, err, io.EOF)
+		require.Equal(t, "4:resource", act)
+	})
+}
+
+// infiniteReader - reads endless message without delimiter
+type infiniteReader struct{}
+
+func (infiniteReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 'a'
+	}
+	return len(b), nil
 }
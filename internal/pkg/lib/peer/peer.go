@@ -0,0 +1,45 @@
+package peer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+)
+
+// Peer - connected client data
+// TLS - uses if connection is served over tls
+type Peer struct {
+	Addr net.Addr
+	TLS  *tls.ConnectionState
+}
+
+// IP - returns peer ip or nil if address has no ip, e.g. unix socket
+func (p Peer) IP() net.IP {
+	switch addr := p.Addr.(type) {
+	case *net.TCPAddr:
+		return addr.IP
+	case *net.UDPAddr:
+		return addr.IP
+	case nil:
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+type peerKey struct{}
+
+// NewContext - returns context with peer
+func NewContext(ctx context.Context, p Peer) context.Context {
+	return context.WithValue(ctx, peerKey{}, p)
+}
+
+// FromContext - returns peer from context
+func FromContext(ctx context.Context) (p Peer, ok bool) {
+	p, ok = ctx.Value(peerKey{}).(Peer)
+	return
+}
@@ -0,0 +1,56 @@
+package pipe
+
+import (
+	"context"
+	"io"
+	"net"
+)
+
+// Pipe - copy data between connections in both directions
+// until both directions reach EOF, one of them fails or context is done.
+// When one direction reaches EOF, writing side of the other connection is closed
+// if it supports half-close, otherwise the connection is closed
+func Pipe(ctx context.Context, a net.Conn, b net.Conn) error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			a.Close()
+			b.Close()
+		case <-stop:
+		}
+	}()
+
+	errs := make(chan error, 2)
+	go func() { errs <- copyHalf(a, b) }()
+	go func() { errs <- copyHalf(b, a) }()
+
+	var err error
+	for i := 0; i < 2; i++ {
+		if copyErr := <-errs; copyErr != nil && err == nil {
+			err = copyErr
+			a.Close()
+			b.Close()
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// copyHalf - copy src to dst and close writing side of dst
+func copyHalf(dst net.Conn, src net.Conn) error {
+	_, err := io.Copy(dst, src)
+
+	if conn, ok := dst.(interface{ CloseWrite() error }); ok {
+		conn.CloseWrite()
+	} else {
+		dst.Close()
+	}
+
+	return err
+}
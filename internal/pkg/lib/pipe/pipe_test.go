@@ -0,0 +1,99 @@
+package pipe
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tcpPair - returns both ends of tcp connection
+func tcpPair(t *testing.T) (net.Conn, net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+
+	server := <-accepted
+	require.NotNil(t, server)
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return client, server
+}
+
+func Test_Pipe(t *testing.T) {
+	t.Run("pipe both directions ok", func(t *testing.T) {
+		client, proxyIn := tcpPair(t)
+		proxyOut, backend := tcpPair(t)
+
+		done := make(chan error, 1)
+		go func() { done <- Pipe(context.Background(), proxyIn, proxyOut) }()
+
+		_, err := client.Write([]byte("ping"))
+		require.NoError(t, err)
+		require.NoError(t, client.(*net.TCPConn).CloseWrite())
+
+		request, err := io.ReadAll(backend)
+		require.NoError(t, err)
+		require.Equal(t, "ping", string(request))
+
+		_, err = backend.Write([]byte("pong"))
+		require.NoError(t, err)
+		require.NoError(t, backend.Close())
+
+		response, err := io.ReadAll(client)
+		require.NoError(t, err)
+		require.Equal(t, "pong", string(response))
+
+		require.NoError(t, <-done)
+	})
+
+	t.Run("pipe without half-close ok", func(t *testing.T) {
+		client, proxyIn := net.Pipe()
+		proxyOut, backend := net.Pipe()
+
+		done := make(chan error, 1)
+		go func() { done <- Pipe(context.Background(), proxyIn, proxyOut) }()
+
+		go func() {
+			client.Write([]byte("ping"))
+			client.Close()
+		}()
+
+		request, err := io.ReadAll(backend)
+		require.NoError(t, err)
+		require.Equal(t, "ping", string(request))
+
+		backend.Close()
+		<-done
+	})
+
+	t.Run("pipe canceled", func(t *testing.T) {
+		client, proxyIn := tcpPair(t)
+		proxyOut, _ := tcpPair(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := Pipe(ctx, proxyIn, proxyOut)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		_, err = io.ReadAll(client)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,9 @@
+package proxyproto
+
+import "errors"
+
+// Errors
+var (
+	ErrHeaderNotFound  = errors.New("proxy protocol header not found")
+	ErrIncorrectHeader = errors.New("incorrect proxy protocol header")
+)
@@ -0,0 +1,274 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxLengthV1 - max length of v1 header including CRLF
+	maxLengthV1 = 107
+
+	// lengthV2 - length of fixed part of v2 header
+	lengthV2 = 16
+
+	commandLocal = 0x0
+	commandProxy = 0x1
+
+	familyUnspec = 0x0
+	familyInet   = 0x1
+	familyInet6  = 0x2
+	familyUnix   = 0x3
+
+	lengthInet  = 12
+	lengthInet6 = 36
+	lengthUnix  = 216
+)
+
+var (
+	signatureV1 = []byte("PROXY ")
+	signatureV2 = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// Header - PROXY protocol header
+// Source and Destination are nil if proxy didn't pass addresses,
+// e.g. LOCAL command of v2 or UNKNOWN protocol of v1
+type Header struct {
+	Version     int
+	Source      net.Addr
+	Destination net.Addr
+}
+
+// ReadHeader - read v1 or v2 PROXY protocol header
+func ReadHeader(r *bufio.Reader) (*Header, error) {
+	signature, err := r.Peek(len(signatureV1))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(signature, signatureV1) {
+		return readHeaderV1(r)
+	}
+
+	signature, err = r.Peek(len(signatureV2))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(signature, signatureV2) {
+		return readHeaderV2(r)
+	}
+
+	return nil, ErrHeaderNotFound
+}
+
+// readHeaderV1 - read text header
+// Format - PROXY TCP4|TCP6|UNKNOWN src_ip dst_ip src_port dst_port\r\n
+func readHeaderV1(r *bufio.Reader) (*Header, error) {
+	var line []byte
+	for len(line) <= maxLengthV1 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if len(line) > maxLengthV1 || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrIncorrectHeader
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	header := &Header{Version: 1}
+
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return header, nil
+	}
+	if len(fields) != 6 {
+		return nil, ErrIncorrectHeader
+	}
+
+	srcIP, dstIP := net.ParseIP(fields[2]), net.ParseIP(fields[3])
+	if srcIP == nil || dstIP == nil {
+		return nil, ErrIncorrectHeader
+	}
+
+	switch fields[1] {
+	case "TCP4":
+		if srcIP.To4() == nil || dstIP.To4() == nil {
+			return nil, ErrIncorrectHeader
+		}
+	case "TCP6":
+		if srcIP.To4() != nil || dstIP.To4() != nil {
+			return nil, ErrIncorrectHeader
+		}
+	default:
+		return nil, ErrIncorrectHeader
+	}
+
+	srcPort, err := parsePort(fields[4])
+	if err != nil {
+		return nil, err
+	}
+	dstPort, err := parsePort(fields[5])
+	if err != nil {
+		return nil, err
+	}
+
+	header.Source = &net.TCPAddr{IP: srcIP, Port: srcPort}
+	header.Destination = &net.TCPAddr{IP: dstIP, Port: dstPort}
+
+	return header, nil
+}
+
+// readHeaderV2 - read binary header
+// Format - signature(12) ver_cmd(1) family(1) length(2) addresses(length)
+func readHeaderV2(r *bufio.Reader) (*Header, error) {
+	fixed := make([]byte, lengthV2)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+
+	version, command := fixed[12]>>4, fixed[12]&0x0F
+	family := fixed[13] >> 4
+	length := int(binary.BigEndian.Uint16(fixed[14:16]))
+
+	if version != 2 {
+		return nil, ErrIncorrectHeader
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	header := &Header{Version: 2}
+
+	switch command {
+	case commandLocal:
+		return header, nil
+	case commandProxy:
+	default:
+		return nil, ErrIncorrectHeader
+	}
+
+	switch family {
+	case familyUnspec:
+		return header, nil
+	case familyInet:
+		if length < lengthInet {
+			return nil, ErrIncorrectHeader
+		}
+		header.Source = &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}
+		header.Destination = &net.TCPAddr{
+			IP:   net.IP(payload[4:8]),
+			Port: int(binary.BigEndian.Uint16(payload[10:12])),
+		}
+	case familyInet6:
+		if length < lengthInet6 {
+			return nil, ErrIncorrectHeader
+		}
+		header.Source = &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}
+		header.Destination = &net.TCPAddr{
+			IP:   net.IP(payload[16:32]),
+			Port: int(binary.BigEndian.Uint16(payload[34:36])),
+		}
+	case familyUnix:
+		if length < lengthUnix {
+			return nil, ErrIncorrectHeader
+		}
+		header.Source = &net.UnixAddr{Name: unixPath(payload[0:108]), Net: "unix"}
+		header.Destination = &net.UnixAddr{Name: unixPath(payload[108:216]), Net: "unix"}
+	default:
+		return nil, ErrIncorrectHeader
+	}
+
+	return header, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || (len(s) > 1 && s[0] == '0') {
+		return 0, ErrIncorrectHeader
+	}
+	return int(port), nil
+}
+
+func unixPath(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// NewConn - read PROXY protocol header from connection
+// Returns connection with addresses passed by proxy
+func NewConn(conn net.Conn) (*Conn, error) {
+	reader := bufio.NewReader(conn)
+
+	header, err := ReadHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		Conn:       conn,
+		reader:     reader,
+		header:     header,
+		remoteAddr: conn.RemoteAddr(),
+		localAddr:  conn.LocalAddr(),
+	}
+	if header.Source != nil {
+		c.remoteAddr = header.Source
+	}
+	if header.Destination != nil {
+		c.localAddr = header.Destination
+	}
+
+	return c, nil
+}
+
+// Conn - connection accepted from proxy
+type Conn struct {
+	net.Conn
+	reader     *bufio.Reader
+	header     *Header
+	remoteAddr net.Addr
+	localAddr  net.Addr
+}
+
+// Read - read data following PROXY protocol header
+func (c *Conn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// RemoteAddr - returns client address passed by proxy or proxy address if it's not passed
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// LocalAddr - returns destination address passed by proxy or local address if it's not passed
+func (c *Conn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+// ProxyAddr - returns proxy address
+func (c *Conn) ProxyAddr() net.Addr {
+	return c.Conn.RemoteAddr()
+}
+
+// Header - returns PROXY protocol header
+func (c *Conn) Header() *Header {
+	return c.header
+}
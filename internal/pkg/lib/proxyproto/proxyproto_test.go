@@ -0,0 +1,161 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadHeader(t *testing.T) {
+	t.Run("v1 tcp4 ok", func(t *testing.T) {
+		r := bufio.NewReader(strings.NewReader("PROXY TCP4 192.168.0.1 10.0.0.1 56324 8080\r\n1:\n"))
+
+		header, err := ReadHeader(r)
+		require.NoError(t, err)
+		require.Equal(t, 1, header.Version)
+		require.Equal(t, "192.168.0.1:56324", header.Source.String())
+		require.Equal(t, "10.0.0.1:8080", header.Destination.String())
+
+		rest, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "1:\n", string(rest))
+	})
+
+	t.Run("v1 tcp6 ok", func(t *testing.T) {
+		r := bufio.NewReader(strings.NewReader("PROXY TCP6 2001:db8::1 2001:db8::2 56324 8080\r\n"))
+
+		header, err := ReadHeader(r)
+		require.NoError(t, err)
+		require.Equal(t, "[2001:db8::1]:56324", header.Source.String())
+		require.Equal(t, "[2001:db8::2]:8080", header.Destination.String())
+	})
+
+	t.Run("v1 unknown ok", func(t *testing.T) {
+		r := bufio.NewReader(strings.NewReader("PROXY UNKNOWN\r\n"))
+
+		header, err := ReadHeader(r)
+		require.NoError(t, err)
+		require.Equal(t, 1, header.Version)
+		require.Nil(t, header.Source)
+		require.Nil(t, header.Destination)
+	})
+
+	t.Run("v1 failed", func(t *testing.T) {
+		headers := []string{
+			"PROXY TCP4 192.168.0.1 10.0.0.1 56324\r\n",
+			"PROXY TCP4 2001:db8::1 10.0.0.1 56324 8080\r\n",
+			"PROXY TCP6 192.168.0.1 10.0.0.1 56324 8080\r\n",
+			"PROXY UDP4 192.168.0.1 10.0.0.1 56324 8080\r\n",
+			"PROXY TCP4 192.168.0.1 10.0.0.1 65536 8080\r\n",
+			"PROXY TCP4 192.168.0.1 10.0.0.1 056324 8080\r\n",
+			"PROXY TCP4 192.168.0.1 10.0.0.1 56324 8080\n",
+			"PROXY " + strings.Repeat("A", 120) + "\r\n",
+		}
+		for _, h := range headers {
+			_, err := ReadHeader(bufio.NewReader(strings.NewReader(h)))
+			require.EqualError(t, ErrIncorrectHeader, err.Error(), h)
+		}
+	})
+
+	t.Run("v2 tcp4 ok", func(t *testing.T) {
+		addrs := []byte{192, 168, 0, 1, 10, 0, 0, 1, 0xDC, 0x04, 0x1F, 0x90}
+		// TLV must be skipped
+		tlv := []byte{0x04, 0x00, 0x01, 0x00}
+		r := bufio.NewReader(bytes.NewReader(append(headerV2(0x21, 0x11, append(addrs, tlv...)), []byte("1:\n")...)))
+
+		header, err := ReadHeader(r)
+		require.NoError(t, err)
+		require.Equal(t, 2, header.Version)
+		require.Equal(t, "192.168.0.1:56324", header.Source.String())
+		require.Equal(t, "10.0.0.1:8080", header.Destination.String())
+
+		rest, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "1:\n", string(rest))
+	})
+
+	t.Run("v2 tcp6 ok", func(t *testing.T) {
+		addrs := make([]byte, 36)
+		copy(addrs[0:16], net.ParseIP("2001:db8::1"))
+		copy(addrs[16:32], net.ParseIP("2001:db8::2"))
+		binary.BigEndian.PutUint16(addrs[32:34], 56324)
+		binary.BigEndian.PutUint16(addrs[34:36], 8080)
+
+		header, err := ReadHeader(bufio.NewReader(bytes.NewReader(headerV2(0x21, 0x21, addrs))))
+		require.NoError(t, err)
+		require.Equal(t, "[2001:db8::1]:56324", header.Source.String())
+		require.Equal(t, "[2001:db8::2]:8080", header.Destination.String())
+	})
+
+	t.Run("v2 unix ok", func(t *testing.T) {
+		addrs := make([]byte, 216)
+		copy(addrs[0:108], "/var/run/client.sock")
+		copy(addrs[108:216], "/var/run/server.sock")
+
+		header, err := ReadHeader(bufio.NewReader(bytes.NewReader(headerV2(0x21, 0x31, addrs))))
+		require.NoError(t, err)
+		require.Equal(t, "/var/run/client.sock", header.Source.String())
+		require.Equal(t, "/var/run/server.sock", header.Destination.String())
+	})
+
+	t.Run("v2 local ok", func(t *testing.T) {
+		header, err := ReadHeader(bufio.NewReader(bytes.NewReader(headerV2(0x20, 0x00, nil))))
+		require.NoError(t, err)
+		require.Equal(t, 2, header.Version)
+		require.Nil(t, header.Source)
+	})
+
+	t.Run("v2 failed", func(t *testing.T) {
+		headers := [][]byte{
+			headerV2(0x31, 0x11, make([]byte, 12)),
+			headerV2(0x22, 0x11, make([]byte, 12)),
+			headerV2(0x21, 0x11, make([]byte, 8)),
+			headerV2(0x21, 0x21, make([]byte, 12)),
+			headerV2(0x21, 0x41, make([]byte, 12)),
+		}
+		for _, h := range headers {
+			_, err := ReadHeader(bufio.NewReader(bytes.NewReader(h)))
+			require.EqualError(t, ErrIncorrectHeader, err.Error())
+		}
+	})
+
+	t.Run("header not found", func(t *testing.T) {
+		_, err := ReadHeader(bufio.NewReader(strings.NewReader("1:\n3:1:5:20231102192537:resource::Cxphfw==:MA==\n")))
+		require.EqualError(t, ErrHeaderNotFound, err.Error())
+	})
+}
+
+func Test_Conn(t *testing.T) {
+	t.Run("conn ok", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		defer client.Close()
+
+		go func() {
+			client.Write([]byte("PROXY TCP4 192.168.0.1 10.0.0.1 56324 8080\r\n1:\n"))
+		}()
+
+		conn, err := NewConn(server)
+		require.NoError(t, err)
+		require.Equal(t, "192.168.0.1:56324", conn.RemoteAddr().String())
+		require.Equal(t, "10.0.0.1:8080", conn.LocalAddr().String())
+		require.Equal(t, server.RemoteAddr(), conn.ProxyAddr())
+
+		msg, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "1:\n", msg)
+	})
+}
+
+func headerV2(verCmd byte, family byte, payload []byte) []byte {
+	header := append([]byte{}, signatureV2...)
+	header = append(header, verCmd, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:16], uint16(len(payload)))
+	return append(header, payload...)
+}
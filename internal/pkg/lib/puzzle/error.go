@@ -0,0 +1,16 @@
+package puzzle
+
+import "errors"
+
+// Errors
+var (
+	ErrZeroBitsMustBeMoreThanZero = errors.New("zero bits must be more than zero")
+	ErrPuzzleCacheRequired        = errors.New("puzzle cache is required")
+
+	ErrStampRequired   = errors.New("hashcash stamp required")
+	ErrStampNotCorrect = errors.New("hashcash stamp is not correct")
+	ErrStampNotFound   = errors.New("hashcash stamp is not found")
+	ErrStampExpired    = errors.New("hashcash stamp expiration exceeded")
+
+	ErrChallengeNotSolved = errors.New("challenge is not solved")
+)
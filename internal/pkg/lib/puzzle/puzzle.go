@@ -0,0 +1,113 @@
+package puzzle
+
+import (
+	"context"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+)
+
+const defaultMaxAttempts = 100000000
+
+// PuzzleCache - store of issued puzzles
+type PuzzleCache interface {
+	AddWithExp(k string, v struct{}, exp time.Time)
+	Take(k string) (ok bool)
+}
+
+// Opts - options to create new issuer
+type Opts struct {
+	Bits        int
+	TTL         time.Duration
+	PuzzleCache PuzzleCache
+}
+
+// New - create new issuer
+func New(opts Opts) (*Issuer, error) {
+	if opts.Bits <= 0 {
+		return nil, ErrZeroBitsMustBeMoreThanZero
+	}
+	if opts.PuzzleCache == nil {
+		return nil, ErrPuzzleCacheRequired
+	}
+
+	return &Issuer{
+		bits:        opts.Bits,
+		ttl:         opts.TTL,
+		puzzleCache: opts.PuzzleCache,
+	}, nil
+}
+
+// Issuer - issues puzzles bound to resources and redeems their solutions,
+// e.g. for transports other than tcp protocol
+type Issuer struct {
+	bits        int
+	ttl         time.Duration
+	puzzleCache PuzzleCache
+}
+
+// Issue - create new puzzle for resource and remember it until ttl
+func (i *Issuer) Issue(resource string) (hashcash.Header, error) {
+	hashcash, err := hashcash.New(i.bits, resource)
+	if err != nil {
+		return "", err
+	}
+
+	exp := time.Now().Add(i.ttl)
+	i.puzzleCache.AddWithExp(hashcash.Key(), struct{}{}, exp)
+
+	return hashcash.Header(), nil
+}
+
+// Redeem - check solved puzzle of resource and forget it,
+// so each solution is accepted once
+func (i *Issuer) Redeem(stamp string, resource string) error {
+	if stamp == "" {
+		return ErrStampRequired
+	}
+
+	hashcash, err := hashcash.ParseHeader(stamp)
+	if err != nil {
+		return ErrStampNotCorrect
+	}
+	if !hashcash.EqualResource(resource) {
+		return ErrStampNotFound
+	}
+	if !hashcash.IsActual(i.ttl) {
+		return ErrStampExpired
+	}
+
+	ok, err := hashcash.IsSolved()
+	if err != nil || !ok {
+		return ErrStampNotCorrect
+	}
+
+	// Take is the only cache call, so concurrent redeems of one stamp can't both pass
+	if !i.puzzleCache.Take(hashcash.Key()) {
+		return ErrStampNotFound
+	}
+
+	return nil
+}
+
+// Solve - solve issued puzzle
+// Uses defaultMaxAttempts if maxAttempts <= 0
+func Solve(ctx context.Context, challenge string, maxAttempts int) (string, error) {
+	hashcash, err := hashcash.ParseHeader(challenge)
+	if err != nil {
+		return "", ErrChallengeNotSolved
+	}
+
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	if err = hashcash.ComputeContext(ctx, maxAttempts); err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		return "", ErrChallengeNotSolved
+	}
+
+	return string(hashcash.Header()), nil
+}
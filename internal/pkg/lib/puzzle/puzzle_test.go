@@ -0,0 +1,144 @@
+package puzzle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cache"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+	"github.com/stretchr/testify/require"
+)
+
+func newIssuer(t *testing.T, bits int, ttl time.Duration) *Issuer {
+	issuer, err := New(Opts{
+		Bits:        bits,
+		TTL:         ttl,
+		PuzzleCache: cache.New[string, struct{}](context.Background(), cache.Opts{}),
+	})
+	require.NoError(t, err)
+
+	return issuer
+}
+
+func Test_New(t *testing.T) {
+	t.Run("zero bits", func(t *testing.T) {
+		_, err := New(Opts{PuzzleCache: cache.New[string, struct{}](context.Background(), cache.Opts{})})
+		require.ErrorIs(t, err, ErrZeroBitsMustBeMoreThanZero)
+	})
+
+	t.Run("puzzle cache required", func(t *testing.T) {
+		_, err := New(Opts{Bits: 1})
+		require.ErrorIs(t, err, ErrPuzzleCacheRequired)
+	})
+}
+
+func Test_IssueRedeem(t *testing.T) {
+	t.Run("redeem ok", func(t *testing.T) {
+		issuer := newIssuer(t, 2, time.Minute)
+
+		challenge, err := issuer.Issue("127.0.0.1")
+		require.NoError(t, err)
+
+		stamp, err := Solve(context.Background(), string(challenge), 0)
+		require.NoError(t, err)
+
+		require.NoError(t, issuer.Redeem(stamp, "127.0.0.1"))
+		require.ErrorIs(t, issuer.Redeem(stamp, "127.0.0.1"), ErrStampNotFound)
+	})
+
+	t.Run("concurrent redeem ok once", func(t *testing.T) {
+		issuer := newIssuer(t, 2, time.Minute)
+
+		challenge, err := issuer.Issue("127.0.0.1")
+		require.NoError(t, err)
+
+		stamp, err := Solve(context.Background(), string(challenge), 0)
+		require.NoError(t, err)
+
+		var (
+			wg        sync.WaitGroup
+			successes atomic.Int32
+		)
+		for n := 0; n < 64; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if issuer.Redeem(stamp, "127.0.0.1") == nil {
+					successes.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, int32(1), successes.Load())
+	})
+
+	t.Run("redeem failed", func(t *testing.T) {
+		issuer := newIssuer(t, 2, time.Minute)
+
+		challenge, err := issuer.Issue("127.0.0.1")
+		require.NoError(t, err)
+
+		stamp, err := Solve(context.Background(), string(challenge), 0)
+		require.NoError(t, err)
+
+		other, err := hashcash.New(2, "127.0.0.1")
+		require.NoError(t, err)
+		otherStamp, err := Solve(context.Background(), string(other.Header()), 0)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, issuer.Redeem("", "127.0.0.1"), ErrStampRequired)
+		require.ErrorIs(t, issuer.Redeem("1:2:resource", "127.0.0.1"), ErrStampNotCorrect)
+		require.ErrorIs(t, issuer.Redeem(otherStamp, "127.0.0.1"), ErrStampNotFound)
+		require.ErrorIs(t, issuer.Redeem(stamp, "10.0.0.1"), ErrStampNotFound)
+	})
+
+	t.Run("redeem not solved", func(t *testing.T) {
+		issuer := newIssuer(t, 6, time.Minute)
+
+		challenge, err := issuer.Issue("127.0.0.1")
+		require.NoError(t, err)
+
+		ok, err := challenge.IsHashCorrect(6)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		require.ErrorIs(t, issuer.Redeem(string(challenge), "127.0.0.1"), ErrStampNotCorrect)
+	})
+
+	t.Run("redeem expired", func(t *testing.T) {
+		issuer := newIssuer(t, 1, time.Hour)
+
+		challenge, err := issuer.Issue("127.0.0.1")
+		require.NoError(t, err)
+
+		stamp, err := Solve(context.Background(), string(challenge), 0)
+		require.NoError(t, err)
+
+		issuer.ttl = -time.Second
+		require.ErrorIs(t, issuer.Redeem(stamp, "127.0.0.1"), ErrStampExpired)
+	})
+}
+
+func Test_Solve(t *testing.T) {
+	t.Run("incorrect challenge", func(t *testing.T) {
+		_, err := Solve(context.Background(), "2:resource", 0)
+		require.ErrorIs(t, err, ErrChallengeNotSolved)
+	})
+
+	t.Run("max attempts exceeded", func(t *testing.T) {
+		_, err := Solve(context.Background(), "1:5:20231102192537:resource::Cxphfw==:MA==", 100)
+		require.ErrorIs(t, err, ErrChallengeNotSolved)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := Solve(ctx, "1:5:20231102192537:resource::Cxphfw==:MA==", 0)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
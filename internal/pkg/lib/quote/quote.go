@@ -1,6 +1,7 @@
-package main
+package quote
 
-var resources = []string{
+// Quotes - default resources sent by server
+var Quotes = []string{
 	`For instance, on the planet Earth, man had always assumed that he was more intelligent than dolphins because he had achieved so much—the wheel, New York, wars and so on—whilst all the dolphins had ever done was muck about in the water having a good time. But conversely, the dolphins had always believed that they were far more intelligent than man—for precisely the same reasons.`,
 	`He felt that his whole life was some kind of dream and he sometimes wondered whose it was and whether they were enjoying it.`,
 	`This planet has—or rather had—a problem, which was this: most of the people living on it were unhappy for pretty much of the time. Many solutions were suggested for this problem, but most of these were largely concerned with the movement of small green pieces of paper, which was odd because on the whole it wasn't the small green pieces of paper that were unhappy.`,
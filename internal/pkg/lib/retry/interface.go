@@ -0,0 +1,6 @@
+package retry
+
+// Logger - logger interface
+type Logger interface {
+	Info(msg string, args ...any)
+}
@@ -0,0 +1,109 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const unlimitedBackoff = time.Duration(math.MaxInt64 / 2)
+
+// Opts - options to create new retry policy
+// MaxAttempts - max number of attempts including the first one, retries are disabled if value <= 1
+// InitialBackoff - delay before the first retry, doubled for every next one up to MaxBackoff
+// MaxBackoff - upper limit of delay, unlimited if value <= 0
+// Jitter - fraction of delay randomized to spread retries of many clients, from 0 to 1
+// Retryable - reports if error should be retried, all errors are retried if it's nil
+type Opts struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64
+	Retryable      func(err error) bool
+	Logger         Logger
+}
+
+// New - create new retry policy
+func New(opts Opts) *Policy {
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = unlimitedBackoff
+	}
+
+	jitter := opts.Jitter
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	return &Policy{
+		maxAttempts:    opts.MaxAttempts,
+		initialBackoff: opts.InitialBackoff,
+		maxBackoff:     maxBackoff,
+		jitter:         jitter,
+		retryable:      opts.Retryable,
+		logger:         opts.Logger,
+	}
+}
+
+// Policy - retry policy with exponential backoff and jitter
+type Policy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	jitter         float64
+	retryable      func(err error) bool
+	logger         Logger
+}
+
+// Do - call fn until it succeeds, returns not retryable error or attempts are exhausted
+// Returns the last error of fn or context error if context is done while waiting
+func (p *Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "retry.Policy.Do"
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= p.maxAttempts || ctx.Err() != nil {
+			return err
+		}
+		if p.retryable != nil && !p.retryable(err) {
+			return err
+		}
+
+		delay := p.Delay(attempt)
+		p.logger.Info("retrying", "op", op, "attempt", attempt, "delay", delay, "error", err.Error())
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Delay - returns delay after failed attempt, attempts start from 1
+// Delay is a random value in [backoff * (1 - jitter), backoff]
+func (p *Policy) Delay(attempt int) time.Duration {
+	backoff := p.initialBackoff
+	for i := 1; i < attempt && backoff < p.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+
+	spread := time.Duration(float64(backoff) * p.jitter)
+	if spread <= 0 {
+		return backoff
+	}
+
+	return backoff - time.Duration(rand.Int63n(int64(spread)+1))
+}
@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errTest = errors.New("test error")
+
+type nopLogger struct{}
+
+func (nopLogger) Info(msg string, args ...any) {}
+
+func Test_Policy(t *testing.T) {
+	t.Run("succeeds after retries", func(t *testing.T) {
+		p := New(Opts{MaxAttempts: 3, InitialBackoff: time.Millisecond, Logger: nopLogger{}})
+
+		calls := 0
+		err := p.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return errTest
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		p := New(Opts{MaxAttempts: 2, InitialBackoff: time.Millisecond, Logger: nopLogger{}})
+
+		calls := 0
+		err := p.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return errTest
+		})
+		require.ErrorIs(t, err, errTest)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("retries disabled", func(t *testing.T) {
+		p := New(Opts{Logger: nopLogger{}})
+
+		calls := 0
+		err := p.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return errTest
+		})
+		require.ErrorIs(t, err, errTest)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("not retryable error", func(t *testing.T) {
+		p := New(Opts{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			Retryable:      func(err error) bool { return !errors.Is(err, errTest) },
+			Logger:         nopLogger{},
+		})
+
+		calls := 0
+		err := p.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return errTest
+		})
+		require.ErrorIs(t, err, errTest)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("canceled while waiting", func(t *testing.T) {
+		p := New(Opts{MaxAttempts: 3, InitialBackoff: time.Minute, Logger: nopLogger{}})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := p.Do(ctx, func(ctx context.Context) error {
+			return errTest
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("delay grows exponentially up to max backoff", func(t *testing.T) {
+		p := New(Opts{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+
+		require.Equal(t, 100*time.Millisecond, p.Delay(1))
+		require.Equal(t, 200*time.Millisecond, p.Delay(2))
+		require.Equal(t, 400*time.Millisecond, p.Delay(3))
+		require.Equal(t, 800*time.Millisecond, p.Delay(4))
+		require.Equal(t, time.Second, p.Delay(5))
+		require.Equal(t, time.Second, p.Delay(100))
+	})
+
+	t.Run("delay with jitter", func(t *testing.T) {
+		p := New(Opts{InitialBackoff: time.Second, Jitter: 0.5})
+
+		for i := 0; i < 100; i++ {
+			delay := p.Delay(1)
+			require.GreaterOrEqual(t, delay, 500*time.Millisecond)
+			require.LessOrEqual(t, delay, time.Second)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package stamp
+
+import (
+	"time"
+)
+
+// SpentDB - store of spent stamps
+// Spend returns ErrDoubleSpent if stamp is already spent and not expired
+type SpentDB interface {
+	Spend(stamp string, exp time.Time) error
+}
+
+// Opts - options to create new checker
+// Expiry - stamp validity period, zero - stamps never expire
+// Grace - tolerance of clock difference with sender
+// SpentDB - rejects reused stamps if it's set
+type Opts struct {
+	Bits    int
+	Expiry  time.Duration
+	Grace   time.Duration
+	SpentDB SpentDB
+}
+
+// NewChecker - create new checker
+func NewChecker(opts Opts) (*Checker, error) {
+	if opts.Bits < 0 || opts.Bits > maxBits {
+		return nil, ErrIncorrectBits
+	}
+
+	return &Checker{
+		bits:    opts.Bits,
+		expiry:  opts.Expiry,
+		grace:   opts.Grace,
+		spentDB: opts.SpentDB,
+	}, nil
+}
+
+// Checker - checks stamps like reference hashcash tool
+type Checker struct {
+	bits    int
+	expiry  time.Duration
+	grace   time.Duration
+	spentDB SpentDB
+}
+
+// Check - check stamp of resource and mark it as spent
+func (c *Checker) Check(stamp string, resource string) error {
+	s, err := Parse(stamp)
+	if err != nil {
+		return err
+	}
+	if s.Resource() != resource {
+		return ErrResourceNotMatch
+	}
+	if s.Bits() < c.bits {
+		return ErrNotEnoughBits
+	}
+	if s.ZeroBits() < s.Bits() {
+		return ErrHashNotCorrect
+	}
+
+	now := time.Now().UTC()
+	date := s.Date()
+	if date.After(now.Add(c.grace)) {
+		return ErrFutureDate
+	}
+
+	var exp time.Time
+	if c.expiry > 0 {
+		exp = date.Add(c.expiry + c.grace)
+		if !exp.After(now) {
+			return ErrExpired
+		}
+	}
+
+	if c.spentDB != nil {
+		return c.spentDB.Spend(s.String(), exp)
+	}
+
+	return nil
+}
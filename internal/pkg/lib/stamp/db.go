@@ -0,0 +1,222 @@
+package stamp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minCleanSize - number of spent stamps when expired ones are dropped first time
+const minCleanSize = 1024
+
+// maxRecordLength - max length of database line: unix time, space, stamp and line break
+const maxRecordLength = 20 + 1 + maxLength + 1
+
+// OpenDB - open double spend database
+// Spent stamps are kept in memory only if path is empty,
+// otherwise they are loaded from file and appended to it, expired ones are dropped on open.
+// Broken lines, e.g. torn by crash during write, are skipped and dropped on open too
+func OpenDB(path string) (*DB, error) {
+	db := &DB{
+		spent:     make(map[string]time.Time),
+		cleanSize: minCleanSize,
+	}
+	if path == "" {
+		return db, nil
+	}
+
+	if err := db.load(path); err != nil {
+		return nil, err
+	}
+	if err := db.compact(path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	db.file = file
+
+	return db, nil
+}
+
+// DB - double spend database of spent stamps
+// Stamp is kept until its expiration, forever if it has no expiration
+type DB struct {
+	mu        sync.Mutex
+	spent     map[string]time.Time
+	cleanSize int
+	file      *os.File
+}
+
+// Spend - mark stamp as spent until exp
+// Returns ErrDoubleSpent if stamp is already spent and not expired,
+// ErrIncorrectFormat if stamp can't be stored as database line
+func (db *DB) Spend(stamp string, exp time.Time) error {
+	if !isField(stamp) || len(stamp) > maxLength {
+		return ErrIncorrectFormat
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	now := time.Now()
+	if prevExp, ok := db.spent[stamp]; ok && !expired(prevExp, now) {
+		return ErrDoubleSpent
+	}
+
+	if db.file != nil {
+		if _, err := db.file.WriteString(formatRecord(stamp, exp)); err != nil {
+			return err
+		}
+	}
+	db.spent[stamp] = exp
+
+	if len(db.spent) >= db.cleanSize {
+		db.clean(now)
+	}
+
+	return nil
+}
+
+// clean - drop expired stamps from memory
+// Next cleaning is when number of stamps doubles, so it's amortized
+func (db *DB) clean(now time.Time) {
+	for stamp, exp := range db.spent {
+		if expired(exp, now) {
+			delete(db.spent, stamp)
+		}
+	}
+
+	db.cleanSize = 2 * len(db.spent)
+	if db.cleanSize < minCleanSize {
+		db.cleanSize = minCleanSize
+	}
+}
+
+// Close - close database file
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.file == nil {
+		return nil
+	}
+	return db.file.Close()
+}
+
+func (db *DB) load(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	now := time.Now()
+	r := bufio.NewReaderSize(file, maxRecordLength)
+	for {
+		line, err := r.ReadSlice('\n')
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			// Too long line can't be a record, skip it up to line break
+			if err := skipLine(r); err != nil {
+				return err
+			}
+			continue
+		case errors.Is(err, io.EOF):
+			// Line without line break is torn write, skip it
+			return nil
+		case err != nil:
+			return err
+		}
+
+		stamp, exp, err := parseRecord(strings.TrimSuffix(string(line), "\n"))
+		if err != nil {
+			continue
+		}
+		if !expired(exp, now) {
+			db.spent[stamp] = exp
+		}
+	}
+}
+
+// skipLine - read rest of line, line break included
+func skipLine(r *bufio.Reader) error {
+	for {
+		_, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+}
+
+// compact - rewrite file with not expired stamps only
+func (db *DB) compact(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for stamp, exp := range db.spent {
+		if _, err := w.WriteString(formatRecord(stamp, exp)); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// formatRecord - returns database line: expiration unix time, 0 if stamp never expires, and stamp
+func formatRecord(stamp string, exp time.Time) string {
+	var unix int64
+	if !exp.IsZero() {
+		unix = exp.Unix()
+	}
+	return fmt.Sprintf("%d %s\n", unix, stamp)
+}
+
+func parseRecord(line string) (string, time.Time, error) {
+	unixStr, stamp, ok := strings.Cut(line, " ")
+	if !ok || !isField(stamp) || len(stamp) > maxLength {
+		return "", time.Time{}, ErrIncorrectSpentDBRecord
+	}
+
+	unix, err := strconv.ParseInt(unixStr, 10, 64)
+	if err != nil || unix < 0 {
+		return "", time.Time{}, ErrIncorrectSpentDBRecord
+	}
+	if unix == 0 {
+		return stamp, time.Time{}, nil
+	}
+
+	return stamp, time.Unix(unix, 0), nil
+}
+
+func expired(exp time.Time, now time.Time) bool {
+	return !exp.IsZero() && !exp.After(now)
+}
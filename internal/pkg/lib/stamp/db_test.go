@@ -0,0 +1,95 @@
+package stamp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DB(t *testing.T) {
+	t.Run("spend in memory", func(t *testing.T) {
+		db, err := OpenDB("")
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, db.Spend("forever", time.Time{}))
+		require.ErrorIs(t, db.Spend("forever", time.Time{}), ErrDoubleSpent)
+
+		require.NoError(t, db.Spend("expired", time.Now().Add(-time.Second)))
+		require.NoError(t, db.Spend("expired", time.Now().Add(time.Hour)))
+		require.ErrorIs(t, db.Spend("expired", time.Now().Add(time.Hour)), ErrDoubleSpent)
+	})
+
+	t.Run("spent stamps persist", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "spent.db")
+
+		db, err := OpenDB(path)
+		require.NoError(t, err)
+		require.NoError(t, db.Spend("forever", time.Time{}))
+		require.NoError(t, db.Spend("actual", time.Now().Add(time.Hour)))
+		require.NoError(t, db.Spend("expired", time.Now().Add(-time.Second)))
+		require.NoError(t, db.Close())
+
+		db, err = OpenDB(path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.ErrorIs(t, db.Spend("forever", time.Time{}), ErrDoubleSpent)
+		require.ErrorIs(t, db.Spend("actual", time.Time{}), ErrDoubleSpent)
+
+		// Expired stamp is dropped from file on open
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NotContains(t, string(content), "expired")
+		require.NoError(t, db.Spend("expired", time.Time{}))
+	})
+
+	t.Run("expired stamps cleaned", func(t *testing.T) {
+		db, err := OpenDB("")
+		require.NoError(t, err)
+
+		for i := 0; i < minCleanSize; i++ {
+			require.NoError(t, db.Spend(encodeCounter(i), time.Now().Add(-time.Second)))
+		}
+		require.Empty(t, db.spent)
+	})
+
+	t.Run("incorrect stamp", func(t *testing.T) {
+		db, err := OpenDB("")
+		require.NoError(t, err)
+
+		require.ErrorIs(t, db.Spend("", time.Time{}), ErrIncorrectFormat)
+		require.ErrorIs(t, db.Spend("stamp\n0 injected", time.Time{}), ErrIncorrectFormat)
+		require.ErrorIs(t, db.Spend(strings.Repeat("a", maxLength+1), time.Time{}), ErrIncorrectFormat)
+	})
+
+	t.Run("broken records skipped", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "spent.db")
+		content := "never stamp\n" +
+			"0 first\n" +
+			"0 " + strings.Repeat("a", 64*1024) + "\n" +
+			"-1 negative\n" +
+			"0 second\n" +
+			"0 torn"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		db, err := OpenDB(path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.ErrorIs(t, db.Spend("first", time.Time{}), ErrDoubleSpent)
+		require.ErrorIs(t, db.Spend("second", time.Time{}), ErrDoubleSpent)
+		require.NoError(t, db.Spend("torn", time.Time{}))
+		require.NoError(t, db.Spend("negative", time.Time{}))
+
+		// Broken lines are dropped from file on open
+		compacted, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSuffix(string(compacted), "\n"), "\n")
+		require.ElementsMatch(t, []string{"0 first", "0 second", "0 torn", "0 negative"}, lines)
+	})
+}
@@ -0,0 +1,17 @@
+package stamp
+
+import "errors"
+
+// Errors
+var (
+	ErrIncorrectFormat        = errors.New("incorrect stamp format")
+	ErrIncorrectBits          = errors.New("bits must be from 0 to 160")
+	ErrMaxAttemptsExceeded    = errors.New("max attempts to mint stamp exceeded")
+	ErrResourceNotMatch       = errors.New("stamp resource doesn't match")
+	ErrNotEnoughBits          = errors.New("stamp has less bits than required")
+	ErrHashNotCorrect         = errors.New("stamp hash doesn't have claimed bits")
+	ErrExpired                = errors.New("stamp expired")
+	ErrFutureDate             = errors.New("stamp date is in future")
+	ErrDoubleSpent            = errors.New("stamp is already spent")
+	ErrIncorrectSpentDBRecord = errors.New("incorrect spent database record")
+)
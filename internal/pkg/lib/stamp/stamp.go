@@ -0,0 +1,275 @@
+package stamp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderName - name of email header carrying stamp
+const HeaderName = "X-Hashcash"
+
+const (
+	version          = "1"
+	alphabet         = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	randLength       = 16
+	ctxCheckAttempts = 10000
+	maxBits          = sha1.Size * 8
+	maxLength        = 1024
+	maxCounterLength = 11 // base64 digits of max int64
+)
+
+// Date layouts of reference hashcash: YYMMDD[hhmm[ss]]
+const (
+	dateLayoutDay    = "060102"
+	dateLayoutMinute = "0601021504"
+	dateLayoutSecond = "060102150405"
+)
+
+// Stamp - hashcash version 1 stamp compatible with reference hashcash tool
+// Format - 1:bits:date:resource:extension:rand:counter
+// Unlike hashcash package bits are leading zero bits of sha1 hash, not hex digits
+type Stamp struct {
+	bits      int
+	date      string // original date text, stamp is hashed as is
+	resource  string
+	extension string
+	rand      string
+	counter   string
+}
+
+// Bits - returns claimed number of zero bits
+func (s *Stamp) Bits() int {
+	return s.bits
+}
+
+// Date - returns date of stamp, it's UTC without time if stamp has date only
+func (s *Stamp) Date() time.Time {
+	date, _ := parseDate(s.date)
+	return date
+}
+
+// Resource - returns resource, e.g. recipient email address
+func (s *Stamp) Resource() string {
+	return s.resource
+}
+
+// Extension - returns extension, it's ignored in checks
+func (s *Stamp) Extension() string {
+	return s.extension
+}
+
+// Rand - returns random part
+func (s *Stamp) Rand() string {
+	return s.rand
+}
+
+// Counter - returns counter
+func (s *Stamp) Counter() string {
+	return s.counter
+}
+
+// String - returns stamp as it's sent in header
+func (s *Stamp) String() string {
+	return strings.Join([]string{version, strconv.Itoa(s.bits), s.date, s.resource, s.extension, s.rand, s.counter}, ":")
+}
+
+// Header - returns email header line with stamp
+func (s *Stamp) Header() string {
+	return HeaderName + ": " + s.String()
+}
+
+// ZeroBits - returns number of leading zero bits of stamp sha1 hash
+func (s *Stamp) ZeroBits() int {
+	return zeroBits(sha1.Sum([]byte(s.String())))
+}
+
+// Mint - compute stamp with bits zero bits for resource dated today like reference tool does
+// Minting stops when context is done or after maxAttempts
+func Mint(ctx context.Context, resource string, bits int, maxAttempts int) (*Stamp, error) {
+	if bits < 0 || bits > maxBits {
+		return nil, ErrIncorrectBits
+	}
+	if !isField(resource) || strings.Contains(resource, ":") {
+		return nil, ErrIncorrectFormat
+	}
+
+	r, err := randString(randLength)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Stamp{
+		bits:     bits,
+		date:     time.Now().UTC().Format(dateLayoutDay),
+		resource: resource,
+		rand:     r,
+	}
+
+	prefix := s.String()
+	if len(prefix)+maxCounterLength > maxLength {
+		return nil, ErrIncorrectFormat
+	}
+
+	for attempt := 0; attempt <= maxAttempts; attempt++ {
+		if attempt%ctxCheckAttempts == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		counter := encodeCounter(attempt)
+		if zeroBits(sha1.Sum([]byte(prefix+counter))) >= bits {
+			s.counter = counter
+			return s, nil
+		}
+	}
+
+	return nil, ErrMaxAttemptsExceeded
+}
+
+// Parse - parse stamp strictly by version 1 format
+// Stamp is limited to maxLength bytes, its fields can't contain whitespace and control characters
+func Parse(stamp string) (*Stamp, error) {
+	if len(stamp) > maxLength {
+		return nil, ErrIncorrectFormat
+	}
+
+	parts := strings.Split(stamp, ":")
+	if len(parts) != 7 || parts[0] != version {
+		return nil, ErrIncorrectFormat
+	}
+
+	bits, err := strconv.Atoi(parts[1])
+	if err != nil || bits < 0 || bits > maxBits {
+		return nil, ErrIncorrectFormat
+	}
+	if _, err := parseDate(parts[2]); err != nil {
+		return nil, ErrIncorrectFormat
+	}
+	if !isField(parts[3]) || !isExtension(parts[4]) || !isBase64(parts[5]) || !isBase64(parts[6]) {
+		return nil, ErrIncorrectFormat
+	}
+
+	return &Stamp{
+		bits:      bits,
+		date:      parts[2],
+		resource:  parts[3],
+		extension: parts[4],
+		rand:      parts[5],
+		counter:   parts[6],
+	}, nil
+}
+
+// FromHeader - returns stamp from X-Hashcash header line
+func FromHeader(line string) (string, bool) {
+	name, value, ok := strings.Cut(line, ":")
+	if !ok || !strings.EqualFold(strings.TrimSpace(name), HeaderName) {
+		return "", false
+	}
+
+	return strings.TrimSpace(value), true
+}
+
+func parseDate(date string) (time.Time, error) {
+	var layout string
+	switch len(date) {
+	case len(dateLayoutDay):
+		layout = dateLayoutDay
+	case len(dateLayoutMinute):
+		layout = dateLayoutMinute
+	case len(dateLayoutSecond):
+		layout = dateLayoutSecond
+	default:
+		return time.Time{}, ErrIncorrectFormat
+	}
+
+	return time.ParseInLocation(layout, date, time.UTC)
+}
+
+// isField - check if string is not empty and has printable ascii characters only, without whitespace
+func isField(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// isExtension - check if string is empty or has version 1 extension format: name[=value][;name[=value]...]
+func isExtension(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, ext := range strings.Split(s, ";") {
+		name, value, hasValue := strings.Cut(ext, "=")
+		if !isField(name) || strings.Contains(value, "=") {
+			return false
+		}
+		if hasValue && value != "" && !isField(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// isBase64 - check if string is not empty and has characters of base64 alphabet only
+func isBase64(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c != '=' && !strings.ContainsRune(alphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+func randString(length int) (string, error) {
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// encodeCounter - returns counter as number in base64 alphabet
+func encodeCounter(n int) string {
+	if n == 0 {
+		return alphabet[:1]
+	}
+
+	var b []byte
+	for ; n > 0; n /= len(alphabet) {
+		b = append(b, alphabet[n%len(alphabet)])
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func zeroBits(hash [sha1.Size]byte) int {
+	n := 0
+	for _, b := range hash {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
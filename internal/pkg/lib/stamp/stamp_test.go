@@ -0,0 +1,210 @@
+package stamp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Stamps minted by reference hashcash tool:
+// referenceStamp - example of https://en.wikipedia.org/wiki/Hashcash,
+// referenceStampDay - example of hashcash(1) manual, http://www.hashcash.org/docs/hashcash.html,
+// referenceStampForged - referenceStamp with another resource, its hash has 3 zero bits
+const (
+	referenceStamp       = "1:20:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi"
+	referenceStampDay    = "1:20:060408:adam@cypherspace.org::1QTjaYd7niiQA/sc:ePa"
+	referenceStampForged = "1:20:1303030600:anni@cypherspace.org::McMybZIhxKXu57jd:ckvi"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Run("reference stamps ok", func(t *testing.T) {
+		s, err := Parse(referenceStamp)
+		require.NoError(t, err)
+		require.Equal(t, 20, s.Bits())
+		require.Equal(t, time.Date(2013, 3, 3, 6, 0, 0, 0, time.UTC), s.Date())
+		require.Equal(t, "adam@cypherspace.org", s.Resource())
+		require.Equal(t, "", s.Extension())
+		require.Equal(t, "McMybZIhxKXu57jd", s.Rand())
+		require.Equal(t, "ckvi", s.Counter())
+		require.Equal(t, referenceStamp, s.String())
+		require.Equal(t, 20, s.ZeroBits())
+
+		s, err = Parse(referenceStampDay)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2006, 4, 8, 0, 0, 0, 0, time.UTC), s.Date())
+		require.Equal(t, referenceStampDay, s.String())
+		require.GreaterOrEqual(t, s.ZeroBits(), 20)
+	})
+
+	t.Run("forged resource breaks hash", func(t *testing.T) {
+		s, err := Parse(referenceStampForged)
+		require.NoError(t, err)
+		require.Less(t, s.ZeroBits(), 20)
+	})
+
+	t.Run("extensions ok", func(t *testing.T) {
+		for _, extension := range []string{"a", "a=b", "a=", "a=b,c;d;e=f"} {
+			s, err := Parse("1:20:1303030600:adam@cypherspace.org:" + extension + ":McMybZIhxKXu57jd:ckvi")
+			require.NoError(t, err, extension)
+			require.Equal(t, extension, s.Extension())
+		}
+	})
+
+	t.Run("incorrect format", func(t *testing.T) {
+		for _, stamp := range []string{
+			"",
+			"0:20:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org:McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org:::McMybZIhxKXu57jd:ckvi",
+			"1:-1:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			"1:161:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			"1:20:20130303:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			"1:20:20231102192537:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:::McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org::McMybZIh-KXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:",
+			"1:20:1303030600:adam @cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org\n0 x::McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org\x00::McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org:a b:McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org:;a:McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org:=a:McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:adam@cypherspace.org:a=b=c:McMybZIhxKXu57jd:ckvi",
+			"1:20:1303030600:" + strings.Repeat("a", maxLength) + "::McMybZIhxKXu57jd:ckvi",
+		} {
+			_, err := Parse(stamp)
+			require.ErrorIs(t, err, ErrIncorrectFormat, stamp)
+		}
+	})
+}
+
+func Test_Mint(t *testing.T) {
+	t.Run("mint ok", func(t *testing.T) {
+		minted, err := Mint(context.Background(), "adam@cypherspace.org", 12, 1<<30)
+		require.NoError(t, err)
+		require.Equal(t, time.Now().UTC().Format(dateLayoutDay), minted.date)
+		require.Len(t, minted.Rand(), randLength)
+
+		s, err := Parse(minted.String())
+		require.NoError(t, err)
+		require.Equal(t, minted, s)
+		require.GreaterOrEqual(t, s.ZeroBits(), 12)
+		require.Equal(t, HeaderName+": "+minted.String(), minted.Header())
+	})
+
+	t.Run("incorrect resource and bits", func(t *testing.T) {
+		_, err := Mint(context.Background(), "adam:cypherspace.org", 1, 1000)
+		require.ErrorIs(t, err, ErrIncorrectFormat)
+
+		_, err = Mint(context.Background(), "", 1, 1000)
+		require.ErrorIs(t, err, ErrIncorrectFormat)
+
+		_, err = Mint(context.Background(), "adam@cypherspace.org\n", 1, 1000)
+		require.ErrorIs(t, err, ErrIncorrectFormat)
+
+		_, err = Mint(context.Background(), strings.Repeat("a", maxLength), 1, 1000)
+		require.ErrorIs(t, err, ErrIncorrectFormat)
+
+		_, err = Mint(context.Background(), "adam@cypherspace.org", 161, 1000)
+		require.ErrorIs(t, err, ErrIncorrectBits)
+	})
+
+	t.Run("max attempts exceeded", func(t *testing.T) {
+		_, err := Mint(context.Background(), "adam@cypherspace.org", 160, 1000)
+		require.ErrorIs(t, err, ErrMaxAttemptsExceeded)
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := Mint(ctx, "adam@cypherspace.org", 160, 1<<30)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("counter", func(t *testing.T) {
+		require.Equal(t, "A", encodeCounter(0))
+		require.Equal(t, "/", encodeCounter(63))
+		require.Equal(t, "BA", encodeCounter(64))
+		require.Equal(t, "ckvi", encodeCounter(28*64*64*64+36*64*64+47*64+34))
+	})
+}
+
+func Test_FromHeader(t *testing.T) {
+	stamp, ok := FromHeader("X-Hashcash: " + referenceStamp)
+	require.True(t, ok)
+	require.Equal(t, referenceStamp, stamp)
+
+	stamp, ok = FromHeader("x-hashcash:" + referenceStamp + "\r\n")
+	require.True(t, ok)
+	require.Equal(t, referenceStamp, stamp)
+
+	_, ok = FromHeader("Subject: " + referenceStamp)
+	require.False(t, ok)
+}
+
+func newChecker(t *testing.T, opts Opts) *Checker {
+	checker, err := NewChecker(opts)
+	require.NoError(t, err)
+
+	return checker
+}
+
+func Test_Check(t *testing.T) {
+	t.Run("check ok and double spent", func(t *testing.T) {
+		db, err := OpenDB("")
+		require.NoError(t, err)
+		checker := newChecker(t, Opts{Bits: 20, SpentDB: db})
+
+		require.NoError(t, checker.Check(referenceStamp, "adam@cypherspace.org"))
+		require.ErrorIs(t, checker.Check(referenceStamp, "adam@cypherspace.org"), ErrDoubleSpent)
+		require.NoError(t, checker.Check(referenceStampDay, "adam@cypherspace.org"))
+	})
+
+	t.Run("resource not match", func(t *testing.T) {
+		checker := newChecker(t, Opts{Bits: 20})
+		require.ErrorIs(t, checker.Check(referenceStamp, "anni@cypherspace.org"), ErrResourceNotMatch)
+	})
+
+	t.Run("not enough bits", func(t *testing.T) {
+		checker := newChecker(t, Opts{Bits: 21})
+		require.ErrorIs(t, checker.Check(referenceStamp, "adam@cypherspace.org"), ErrNotEnoughBits)
+	})
+
+	t.Run("hash not correct", func(t *testing.T) {
+		checker := newChecker(t, Opts{Bits: 20})
+		require.ErrorIs(t, checker.Check(referenceStampForged, "anni@cypherspace.org"), ErrHashNotCorrect)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		checker := newChecker(t, Opts{Bits: 20, Expiry: 28 * 24 * time.Hour, Grace: 48 * time.Hour})
+		require.ErrorIs(t, checker.Check(referenceStamp, "adam@cypherspace.org"), ErrExpired)
+	})
+
+	t.Run("future date", func(t *testing.T) {
+		checker := newChecker(t, Opts{Grace: time.Hour})
+
+		// Zero bits stamp has correct hash with any counter
+		future := time.Now().UTC().Add(3 * time.Hour).Format(dateLayoutSecond)
+		require.ErrorIs(t, checker.Check("1:0:"+future+":adam@cypherspace.org::abc:A", "adam@cypherspace.org"), ErrFutureDate)
+
+		near := time.Now().UTC().Add(30 * time.Minute).Format(dateLayoutSecond)
+		require.NoError(t, checker.Check("1:0:"+near+":adam@cypherspace.org::abc:A", "adam@cypherspace.org"))
+	})
+
+	t.Run("minted stamp ok", func(t *testing.T) {
+		checker := newChecker(t, Opts{Bits: 10, Expiry: 28 * 24 * time.Hour, Grace: 48 * time.Hour})
+
+		s, err := Mint(context.Background(), "adam@cypherspace.org", 10, 1<<30)
+		require.NoError(t, err)
+		require.NoError(t, checker.Check(s.String(), "adam@cypherspace.org"))
+	})
+
+	t.Run("incorrect bits", func(t *testing.T) {
+		_, err := NewChecker(Opts{Bits: -1})
+		require.ErrorIs(t, err, ErrIncorrectBits)
+	})
+}
@@ -0,0 +1,8 @@
+package trace
+
+import "errors"
+
+// Errors
+var (
+	ErrUnknownExporter = errors.New("unknown trace exporter")
+)
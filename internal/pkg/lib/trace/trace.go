@@ -0,0 +1,103 @@
+package trace
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Exporter - span exporter type
+type Exporter string
+
+// Exporter - supported exporters
+const (
+	ExporterNone   Exporter = "none"
+	ExporterStdout Exporter = "stdout"
+	ExporterOTLP   Exporter = "otlp"
+)
+
+// Opts - options to create new tracer provider
+// Endpoint - OTLP/HTTP collector host:port, uses only by otlp exporter
+type Opts struct {
+	ServiceName string
+	Exporter    Exporter
+	Endpoint    string
+	Insecure    bool
+	Writer      io.Writer
+}
+
+// New - create new tracer provider
+// Returns noop provider if exporter is not set
+func New(ctx context.Context, opts Opts) (*Provider, error) {
+	exporter, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return &Provider{
+			TracerProvider: noop.NewTracerProvider(),
+			shutdown:       func(context.Context) error { return nil },
+		}, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	return &Provider{
+		TracerProvider: provider,
+		shutdown:       provider.Shutdown,
+	}, nil
+}
+
+// Provider - tracer provider which flushes spans on shutdown
+type Provider struct {
+	oteltrace.TracerProvider
+	shutdown func(ctx context.Context) error
+}
+
+// Shutdown - flush spans and stop exporter
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
+
+func newExporter(ctx context.Context, opts Opts) (sdktrace.SpanExporter, error) {
+	switch opts.Exporter {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		writer := opts.Writer
+		if writer == nil {
+			writer = os.Stdout
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(writer))
+	case ExporterOTLP:
+		clientOpts := []otlptracehttp.Option{}
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, clientOpts...)
+	default:
+		return nil, ErrUnknownExporter
+	}
+}
@@ -0,0 +1,46 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_New(t *testing.T) {
+	t.Run("noop provider ok", func(t *testing.T) {
+		provider, err := New(context.Background(), Opts{ServiceName: "test"})
+		require.NoError(t, err)
+
+		_, span := provider.Tracer("test").Start(context.Background(), "span")
+		require.False(t, span.SpanContext().IsValid())
+		span.End()
+
+		require.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	t.Run("stdout provider ok", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		provider, err := New(context.Background(), Opts{
+			ServiceName: "test",
+			Exporter:    ExporterStdout,
+			Writer:      buf,
+		})
+		require.NoError(t, err)
+
+		_, span := provider.Tracer("test").Start(context.Background(), "span")
+		require.True(t, span.SpanContext().IsValid())
+		span.End()
+
+		require.NoError(t, provider.Shutdown(context.Background()))
+		require.Contains(t, buf.String(), `"Name":"span"`)
+		require.Contains(t, buf.String(), span.SpanContext().TraceID().String())
+	})
+
+	t.Run("unknown exporter failed", func(t *testing.T) {
+		_, err := New(context.Background(), Opts{Exporter: "unknown"})
+		require.EqualError(t, ErrUnknownExporter, err.Error())
+	})
+}
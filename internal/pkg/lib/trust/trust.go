@@ -0,0 +1,102 @@
+package trust
+
+import (
+	"crypto/subtle"
+	"net"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cidr"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/peer"
+)
+
+// Opts - options to create new trust policy
+// CIDRs - trusted source networks
+// APIKeys - trusted api keys sent by clients in handshake
+// CertNames - trusted common names or DNS names of verified client certificates
+// ZeroBits - number of zero bits in puzzles of trusted clients, 0 means proof of work is skipped
+type Opts struct {
+	CIDRs     []string
+	APIKeys   []string
+	CertNames []string
+	ZeroBits  int
+}
+
+// New - create new trust policy
+func New(opts Opts) (*Policy, error) {
+	networks, err := cidr.ParseList(opts.CIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Policy{
+		networks:  networks,
+		apiKeys:   opts.APIKeys,
+		certNames: make(map[string]struct{}, len(opts.CertNames)),
+		zeroBits:  opts.ZeroBits,
+	}
+
+	for _, name := range opts.CertNames {
+		p.certNames[name] = struct{}{}
+	}
+
+	return p, nil
+}
+
+// Policy - policy to bypass or reduce proof of work for trusted clients
+type Policy struct {
+	networks  cidr.List
+	apiKeys   []string
+	certNames map[string]struct{}
+	zeroBits  int
+}
+
+// PuzzleZeroBits - returns number of zero bits in client puzzle
+// Returns reduced number for trusted client and unchanged bits for others
+func (p *Policy) PuzzleZeroBits(client peer.Peer, apiKey string, bits int) int {
+	if !p.IsTrusted(client, apiKey) {
+		return bits
+	}
+	if p.zeroBits < bits {
+		return p.zeroBits
+	}
+	return bits
+}
+
+// IsTrusted - check if client is trusted by network, api key or certificate
+func (p *Policy) IsTrusted(client peer.Peer, apiKey string) bool {
+	return p.isTrustedIP(client.IP()) || p.isTrustedAPIKey(apiKey) || p.isTrustedCert(client)
+}
+
+func (p *Policy) isTrustedIP(ip net.IP) bool {
+	return p.networks.Contains(ip)
+}
+
+func (p *Policy) isTrustedAPIKey(apiKey string) bool {
+	if apiKey == "" {
+		return false
+	}
+
+	trusted := false
+	for _, key := range p.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			trusted = true
+		}
+	}
+	return trusted
+}
+
+func (p *Policy) isTrustedCert(client peer.Peer) bool {
+	if client.TLS == nil || len(client.TLS.VerifiedChains) == 0 {
+		return false
+	}
+
+	leaf := client.TLS.VerifiedChains[0][0]
+	if _, ok := p.certNames[leaf.Subject.CommonName]; ok {
+		return true
+	}
+	for _, name := range leaf.DNSNames {
+		if _, ok := p.certNames[name]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package trust
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Policy(t *testing.T) {
+	policy, err := New(Opts{
+		CIDRs:     []string{"10.0.0.0/8"},
+		APIKeys:   []string{"secret"},
+		CertNames: []string{"internal.svc"},
+		ZeroBits:  2,
+	})
+	require.NoError(t, err)
+
+	anonymous := peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 1000}}
+
+	t.Run("trusted by network", func(t *testing.T) {
+		client := peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.1.1.1"), Port: 1000}}
+
+		require.True(t, policy.IsTrusted(client, ""))
+		require.Equal(t, 2, policy.PuzzleZeroBits(client, "", 5))
+	})
+
+	t.Run("trusted by api key", func(t *testing.T) {
+		require.True(t, policy.IsTrusted(anonymous, "secret"))
+		require.Equal(t, 2, policy.PuzzleZeroBits(anonymous, "secret", 5))
+
+		require.False(t, policy.IsTrusted(anonymous, "wrong"))
+		require.False(t, policy.IsTrusted(anonymous, ""))
+	})
+
+	t.Run("trusted by certificate", func(t *testing.T) {
+		client := anonymous
+		client.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{
+				{Subject: pkix.Name{CommonName: "client"}, DNSNames: []string{"internal.svc"}},
+			}},
+		}
+		require.True(t, policy.IsTrusted(client, ""))
+
+		// Certificate is not verified
+		client.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "internal.svc"}}},
+		}
+		require.False(t, policy.IsTrusted(client, ""))
+	})
+
+	t.Run("untrusted keeps bits", func(t *testing.T) {
+		require.False(t, policy.IsTrusted(anonymous, ""))
+		require.Equal(t, 5, policy.PuzzleZeroBits(anonymous, "", 5))
+	})
+
+	t.Run("trusted never increases bits", func(t *testing.T) {
+		require.Equal(t, 1, policy.PuzzleZeroBits(anonymous, "secret", 1))
+	})
+
+	t.Run("unix socket peer has no ip", func(t *testing.T) {
+		client := peer.Peer{Addr: &net.UnixAddr{Name: "@", Net: "unix"}}
+		require.Nil(t, client.IP())
+		require.False(t, policy.IsTrusted(client, ""))
+	})
+}
@@ -0,0 +1,225 @@
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	xwebsocket "golang.org/x/net/websocket"
+)
+
+// handshakeTimeout - max time to read http request of websocket handshake
+const handshakeTimeout = 10 * time.Second
+
+// Listen - listen websocket connections on tcp address and http path
+// Handshake is served over tls (wss) if tlsConfig is set.
+// Requests from any origin are accepted
+func Listen(address string, path string, tlsConfig *tls.Config) (*Listener, error) {
+	if path == "" {
+		path = "/"
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	l := &Listener{
+		listener: listener,
+		conns:    make(chan *Conn),
+		closed:   make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, xwebsocket.Server{Handler: l.handle})
+
+	l.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: handshakeTimeout,
+	}
+	go l.server.Serve(listener)
+
+	return l, nil
+}
+
+// Listener - websocket listener, accepts websocket connections as net.Conn
+type Listener struct {
+	listener  net.Listener
+	server    *http.Server
+	conns     chan *Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept - wait for next websocket connection
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close - stop accepting connections
+// Accepted connections stay open until they are closed
+func (l *Listener) Close() (err error) {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		err = l.server.Close()
+	})
+	return err
+}
+
+// Addr - returns listener network address
+func (l *Listener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// handle - pass connection to Accept and keep it open until it's closed
+func (l *Listener) handle(ws *xwebsocket.Conn) {
+	conn := newConn(ws)
+
+	select {
+	case l.conns <- conn:
+	case <-l.closed:
+		return
+	}
+
+	<-conn.closed
+}
+
+// Dial - connect to websocket server by ws:// or wss:// url
+// tlsConfig is used by wss only, default config is used if it's nil
+func Dial(ctx context.Context, rawURL string, tlsConfig *tls.Config) (*Conn, error) {
+	location, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	origin := &url.URL{Scheme: "http", Host: location.Host}
+	if location.Scheme == "wss" {
+		origin.Scheme = "https"
+	}
+
+	config, err := xwebsocket.NewConfig(rawURL, origin.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	switch location.Scheme {
+	case "ws":
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", hostPort(location, "80"))
+	case "wss":
+		dialer := tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", hostPort(location, "443"))
+	default:
+		return nil, xwebsocket.ErrBadScheme
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	ws, err := xwebsocket.NewClient(config, conn)
+	close(done)
+
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	// Client side connection returns url and origin as addresses,
+	// so the addresses of tcp connection are used
+	wsConn := newConn(ws)
+	wsConn.localAddr = conn.LocalAddr()
+	wsConn.remoteAddr = conn.RemoteAddr()
+
+	return wsConn, nil
+}
+
+// Conn - websocket connection
+// Messages are written as text frames, reads return frame payloads as a stream
+type Conn struct {
+	*xwebsocket.Conn
+
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	tls        *tls.ConnectionState
+	closed     chan struct{}
+	closeOnce  sync.Once
+}
+
+func newConn(ws *xwebsocket.Conn) *Conn {
+	conn := &Conn{
+		Conn:       ws,
+		localAddr:  ws.LocalAddr(),
+		remoteAddr: ws.RemoteAddr(),
+		closed:     make(chan struct{}),
+	}
+
+	// Server side connection returns origin and url as addresses,
+	// so the addresses of http request are used
+	if req := ws.Request(); req != nil {
+		if addr, err := net.ResolveTCPAddr("tcp", req.RemoteAddr); err == nil {
+			conn.remoteAddr = addr
+		}
+		if addr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+			conn.localAddr = addr
+		}
+		conn.tls = req.TLS
+	}
+
+	return conn
+}
+
+// LocalAddr - returns local address of connection
+func (c *Conn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+// RemoteAddr - returns address of connected peer
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// TLS - returns tls state of server side connection handshake served over tls
+func (c *Conn) TLS() *tls.ConnectionState {
+	return c.tls
+}
+
+// Close - send close frame and close connection
+func (c *Conn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() { close(c.closed) })
+	return err
+}
+
+func hostPort(location *url.URL, defaultPort string) string {
+	if location.Port() != "" {
+		return location.Host
+	}
+	return net.JoinHostPort(location.Hostname(), defaultPort)
+}
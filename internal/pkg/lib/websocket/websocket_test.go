@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ListenDial(t *testing.T) {
+	t.Run("messages ok", func(t *testing.T) {
+		listener, err := Listen("127.0.0.1:0", "/pow", nil)
+		require.NoError(t, err)
+		defer listener.Close()
+
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				accepted <- conn
+			}
+		}()
+
+		client, err := Dial(context.Background(), "ws://"+listener.Addr().String()+"/pow", nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		server := <-accepted
+		defer server.Close()
+
+		require.Equal(t, client.LocalAddr().String(), server.RemoteAddr().String())
+		require.Equal(t, listener.Addr().String(), client.RemoteAddr().String())
+		require.Nil(t, server.(*Conn).TLS())
+
+		_, err = client.Write([]byte("1:\n"))
+		require.NoError(t, err)
+
+		msg, err := bufio.NewReader(server).ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "1:\n", msg)
+
+		_, err = server.Write([]byte("2:puzzle\n"))
+		require.NoError(t, err)
+
+		msg, err = bufio.NewReader(client).ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "2:puzzle\n", msg)
+	})
+
+	t.Run("accepted connection outlives listener", func(t *testing.T) {
+		listener, err := Listen("127.0.0.1:0", "/", nil)
+		require.NoError(t, err)
+
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				accepted <- conn
+			}
+		}()
+
+		client, err := Dial(context.Background(), "ws://"+listener.Addr().String(), nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		server := <-accepted
+		defer server.Close()
+
+		require.NoError(t, listener.Close())
+		_, err = listener.Accept()
+		require.ErrorIs(t, err, net.ErrClosed)
+
+		_, err = server.Write([]byte("4:resource\n"))
+		require.NoError(t, err)
+
+		msg, err := bufio.NewReader(client).ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "4:resource\n", msg)
+	})
+
+	t.Run("dial canceled", func(t *testing.T) {
+		tcp, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer tcp.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = Dial(ctx, "ws://"+tcp.Addr().String(), nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("bad scheme", func(t *testing.T) {
+		_, err := Dial(context.Background(), "http://127.0.0.1:80", nil)
+		require.Error(t, err)
+	})
+}
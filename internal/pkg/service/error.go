@@ -9,6 +9,7 @@ import (
 // Errors
 var (
 	ErrIncorrectMessageFormat     = errors.New("incorrect message format")
+	ErrMessageTooLarge            = errors.New("message too large")
 	ErrTimeoutExceeded            = errors.New("timeout exceeded")
 	ErrUnknownCommand             = errors.New("unknown command")
 	ErrHashcashHeaderNotFound     = errors.New("hashcash header not found")
@@ -16,8 +17,29 @@ var (
 	ErrHashcashExpirationExceeded = errors.New("hashcash expiration exceeded")
 	ErrInternalError              = errors.New("internal error")
 	ErrResponseCommandNotcorrect  = errors.New("response command is not correct")
+	ErrServerShuttingDown         = errors.New("server is shutting down")
+	ErrTooManyConnections         = errors.New("too many connections")
+	ErrTooManyConnectionsFromIP   = errors.New("too many connections from ip")
+	ErrRequestCanceled            = errors.New("request canceled")
+	ErrPuzzleTooDifficult         = errors.New("puzzle is too difficult")
+	ErrPuzzleSolveBudgetExceeded  = errors.New("puzzle solve time exceeds budget")
 )
 
+// ServerError - error received from server
+// Matches errors with the same text, e.g. errors.Is(err, ErrServerShuttingDown)
+type ServerError struct {
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return e.Message
+}
+
+// Is - check if target error has the same text
+func (e *ServerError) Is(target error) bool {
+	return target != nil && target.Error() == e.Message
+}
+
 func errorMessage(err error) message.Message {
 	return message.Message{
 		Command: message.CommandError,
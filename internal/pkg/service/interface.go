@@ -1,12 +1,17 @@
 package service
 
-import "time"
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/peer"
+)
 
 // PuzzleCache - puzzle cache interface
 type PuzzleCache interface {
 	AddWithExp(k string, v struct{}, exp time.Time)
-	Get(k string) (v struct{}, ok bool)
-	Delete(k string)
+	Take(k string) (ok bool)
 }
 
 // ResourceCache - resource cache interface
@@ -26,13 +31,46 @@ type ErrorChecker interface {
 	IsTimeout(err error) bool
 }
 
+// PuzzlePolicy - policy consulted before issuing a puzzle
+// Returns number of zero bits in client puzzle, value <= 0 means proof of work is skipped
+type PuzzlePolicy interface {
+	PuzzleZeroBits(client peer.Peer, apiKey string, bits int) int
+}
+
+// FailureReporter - reporter of clients that submit invalid or expired solutions
+// Returns true if client ip has been banned
+type FailureReporter interface {
+	ReportFailure(ip net.IP) bool
+}
+
+// ResourceBinder - binder of puzzles to clients
+// Returns puzzle resource for client address and identity supplied by client
+type ResourceBinder interface {
+	Resource(clientID string, identity string) (string, error)
+}
+
+// ResourceHandler - handler of resource step, replaces random resource from ResourceCache
+// Returns resource to send to client who passed proof of work
+type ResourceHandler interface {
+	HandleResource(ctx context.Context, clientID string) (string, error)
+}
+
 // ServerConfig - server config interface
+// PuzzleAttempts - difficulty in expected attempts, it overrides PuzzleZeroBits if value > 0
 type ServerConfig interface {
 	PuzzleTTL() time.Duration
 	PuzzleZeroBits() int
+	PuzzleAttempts() float64
+	TracePropagation() bool
 }
 
 // ClientConfig - client config interface
+// PuzzleMaxZeroBits, PuzzleSolveBudget - puzzle limits, disabled if value <= 0
 type ClientConfig interface {
 	PuzzleComputeMaxAttempts() int
+	PuzzleMaxZeroBits() int
+	PuzzleSolveBudget() time.Duration
+	APIKey() string
+	Identity() string
+	TracePropagation() bool
 }
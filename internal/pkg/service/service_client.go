@@ -1,18 +1,25 @@
 package service
 
 import (
-	"bufio"
-	"errors"
+	"context"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// hashRateDuration - duration of local hash rate measuring
+const hashRateDuration = 200 * time.Millisecond
+
 // Opts - options to create new cache instance
 type ClientOpts struct {
 	Logger Logger
 	Config ClientConfig
+	Tracer trace.Tracer
 }
 
 // NewClient - create new client-side service
@@ -20,6 +27,7 @@ func NewClient(opts ClientOpts) *Client {
 	return &Client{
 		logger: opts.Logger,
 		config: opts.Config,
+		tracer: opts.Tracer,
 	}
 }
 
@@ -27,48 +35,204 @@ func NewClient(opts ClientOpts) *Client {
 type Client struct {
 	logger Logger
 	config ClientConfig
+	tracer trace.Tracer
+
+	hashRateMu sync.Mutex
+	hashRate   float64
 }
 
 // RequestResource - request server resource
-func (c *Client) RequestResource(clientID string, rw io.ReadWriter) (resource string, err error) {
+// Puzzle solving stops when context is done
+func (c *Client) RequestResource(ctx context.Context, clientID string, rw io.ReadWriter) (resource string, err error) {
 	const op = "service.Client.RequestResource"
 
+	ctx, span := c.tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("client.id", clientID)),
+	)
+	defer func() {
+		if err != nil {
+			recordError(ctx, err)
+		}
+		span.End()
+	}()
+
 	c.logger.Info("connection established", "clientID", clientID)
 
+	if c.config.APIKey() != "" {
+		if err = c.requestHandshake(ctx, clientID, rw); err != nil {
+			c.logger.Error(err.Error(), "op", op, "clientID", clientID)
+			return
+		}
+	}
+
+	puzzle, err := c.requestPuzzle(ctx, clientID, rw)
+	if err != nil {
+		c.logger.Error(err.Error(), "op", op, "clientID", clientID)
+		return
+	}
+
+	// Server sends empty puzzle if proof of work is skipped for trusted client
+	var solution string
+	if puzzle != "" {
+		solution, err = c.solvePuzzle(ctx, clientID, puzzle)
+		if err != nil {
+			c.logger.Error(err.Error(), "op", op, "clientID", clientID)
+			return
+		}
+	} else {
+		c.logger.Info("proof of work skipped", "clientID", clientID)
+	}
+
+	resource, err = c.redeemSolution(ctx, clientID, solution, rw)
+	if err != nil {
+		c.logger.Error(err.Error(), "op", op, "clientID", clientID)
+		return
+	}
+
+	return
+}
+
+func (c *Client) requestHandshake(ctx context.Context, clientID string, rw io.ReadWriter) (err error) {
+	const op = "service.Client.requestHandshake"
+
+	ctx, span := c.tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	handshakeReqMsg := message.Message{
+		Command: message.CommandRequestHandshake,
+		Payload: c.config.APIKey(),
+	}
+
+	c.logger.Info("sending handshake", "clientID", clientID)
+	if _, err = c.request(ctx, clientID, handshakeReqMsg, rw); err != nil {
+		recordError(ctx, err)
+		return
+	}
+	c.logger.Info("handshake accepted", "clientID", clientID)
+
+	return
+}
+
+func (c *Client) requestPuzzle(ctx context.Context, clientID string, rw io.ReadWriter) (puzzle string, err error) {
+	const op = "service.Client.requestPuzzle"
+
+	ctx, span := c.tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
 	puzzleReqMsg := message.Message{
 		Command: message.CommandRequestPuzzle,
+		Payload: c.config.Identity(),
 	}
 
 	c.logger.Info("requesting puzzle", "clientID", clientID)
-	puzzle, err := c.request(clientID, puzzleReqMsg, rw)
+	puzzle, err = c.request(ctx, clientID, puzzleReqMsg, rw)
 	if err != nil {
-		c.logger.Error(err.Error(), "op", op, "clientID", clientID)
+		recordError(ctx, err)
 		return
 	}
+	span.SetAttributes(attribute.String("puzzle", puzzle))
 	c.logger.Info("puzzle received", "clientID", clientID, "puzzle", puzzle)
 
+	return
+}
+
+func (c *Client) solvePuzzle(ctx context.Context, clientID string, puzzle string) (solution string, err error) {
+	const op = "service.Client.solvePuzzle"
+
+	ctx, span := c.tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
 	hashcash, err := hashcash.ParseHeader(puzzle)
 	if err != nil {
-		c.logger.Error(err.Error(), "op", op, "clientID", clientID)
+		recordError(ctx, err)
+		return
+	}
+
+	if err = c.checkPuzzle(ctx, clientID, hashcash.Difficulty()); err != nil {
+		recordError(ctx, err)
 		return
 	}
 
 	c.logger.Info("solving puzzle", "clientID", clientID)
-	if err = hashcash.Compute(c.config.PuzzleComputeMaxAttempts()); err != nil {
-		c.logger.Error(err.Error(), "op", op, "clientID", clientID)
+	if err = hashcash.ComputeContext(ctx, c.config.PuzzleComputeMaxAttempts()); err != nil {
+		recordError(ctx, err)
 		return
 	}
+	span.SetAttributes(attribute.Int("counter", hashcash.Counter()))
 	c.logger.Info("puzzle solved", "clientID", clientID, "counter", hashcash.Counter())
 
+	return string(hashcash.Header()), nil
+}
+
+// checkPuzzle - refuse puzzle before solving it
+// if it's more difficult than max zero bits or estimated solve time exceeds budget
+// Difficulty is expected attempts, so puzzles with target are compared too
+func (c *Client) checkPuzzle(ctx context.Context, clientID string, attempts float64) error {
+	if maxBits := c.config.PuzzleMaxZeroBits(); maxBits > 0 && attempts > hashcash.ExpectedAttempts(maxBits) {
+		c.logger.Info(ErrPuzzleTooDifficult.Error(), "clientID", clientID, "attempts", attempts, "max_bits", maxBits)
+		return ErrPuzzleTooDifficult
+	}
+
+	budget := c.config.PuzzleSolveBudget()
+	if budget <= 0 {
+		return nil
+	}
+
+	rate, err := c.measureHashRate(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Compare seconds, estimate of very difficult puzzle overflows time.Duration
+	estimate := attempts / rate
+	if estimate > budget.Seconds() {
+		c.logger.Info(ErrPuzzleSolveBudgetExceeded.Error(), "clientID", clientID, "attempts", attempts,
+			"estimate_seconds", estimate, "budget", budget)
+		return ErrPuzzleSolveBudgetExceeded
+	}
+
+	return nil
+}
+
+// measureHashRate - returns local hash rate, it's measured once on first call
+func (c *Client) measureHashRate(ctx context.Context) (float64, error) {
+	c.hashRateMu.Lock()
+	defer c.hashRateMu.Unlock()
+
+	if c.hashRate > 0 {
+		return c.hashRate, nil
+	}
+
+	rate, err := hashcash.HashRate(ctx, hashRateDuration)
+	if err != nil {
+		return 0, err
+	}
+	if rate <= 0 {
+		return 0, ErrInternalError
+	}
+
+	c.hashRate = rate
+	c.logger.Info("hash rate measured", "attempts_per_second", int(rate))
+
+	return rate, nil
+}
+
+func (c *Client) redeemSolution(ctx context.Context, clientID string, solution string, rw io.ReadWriter) (resource string, err error) {
+	const op = "service.Client.redeemSolution"
+
+	ctx, span := c.tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
 	resourceReqMsg := message.Message{
 		Command: message.CommandRequestResource,
-		Payload: string(hashcash.Header()),
+		Payload: solution,
 	}
 
 	c.logger.Info("requesting resource", "clientID", clientID)
-	resource, err = c.request(clientID, resourceReqMsg, rw)
+	resource, err = c.request(ctx, clientID, resourceReqMsg, rw)
 	if err != nil {
-		c.logger.Error(err.Error(), "op", op, "clientID", clientID)
+		recordError(ctx, err)
 		return
 	}
 	c.logger.Info("resource received", "clientID", clientID, "resource", resource)
@@ -76,12 +240,16 @@ func (c *Client) RequestResource(clientID string, rw io.ReadWriter) (resource st
 	return
 }
 
-func (c *Client) request(clientID string, msg message.Message, rw io.ReadWriter) (payload string, err error) {
+func (c *Client) request(ctx context.Context, clientID string, msg message.Message, rw io.ReadWriter) (payload string, err error) {
+	if c.config.TracePropagation() {
+		injectTrace(ctx, &msg)
+	}
+
 	if err = c.writeMsg(clientID, msg, rw); err != nil {
 		return
 	}
 
-	rawResMsg, err := bufio.NewReader(rw).ReadString(message.DelimiterMessage)
+	rawResMsg, err := message.ReadMessage(rw)
 	if err != nil {
 		return
 	}
@@ -109,7 +277,10 @@ func (s *Client) writeMsg(clientID string, msg message.Message, w io.Writer) (er
 
 func (s *Client) checkResMessage(reqCmd message.Command, resMsg message.Message) (err error) {
 	if resMsg.Command == message.CommandError {
-		return errors.New(resMsg.Payload)
+		return &ServerError{Message: resMsg.Payload}
+	}
+	if reqCmd == message.CommandRequestHandshake && resMsg.Command != message.CommandResponseHandshake {
+		return ErrResponseCommandNotcorrect
 	}
 	if reqCmd == message.CommandRequestPuzzle && resMsg.Command != message.CommandResponsePuzzle {
 		return ErrResponseCommandNotcorrect
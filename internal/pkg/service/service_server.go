@@ -1,54 +1,118 @@
 package service
 
 import (
-	"bufio"
+	"context"
 	"crypto/rand"
+	"errors"
 	"io"
 	"math/big"
+	"sync/atomic"
 	"time"
 
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/difficulty"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
 	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/peer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Opts - options to create new cache instance
 type ServerOpts struct {
-	Logger        Logger
-	Config        ServerConfig
-	PuzzleCache   PuzzleCache
-	ResourceCache ResourceCache
-	ErrorChecker  ErrorChecker
+	Logger          Logger
+	Config          ServerConfig
+	PuzzleCache     PuzzleCache
+	ResourceCache   ResourceCache
+	ErrorChecker    ErrorChecker
+	PuzzlePolicy    PuzzlePolicy
+	FailureReporter FailureReporter
+	ResourceBinder  ResourceBinder
+	ResourceHandler ResourceHandler
+	Tracer          trace.Tracer
 }
 
 // NewServer - create new server-side service
 func NewServer(opts ServerOpts) *Server {
 	return &Server{
-		logger:        opts.Logger,
-		config:        opts.Config,
-		puzzleCache:   opts.PuzzleCache,
-		resourceCache: opts.ResourceCache,
-		errorChecker:  opts.ErrorChecker,
+		logger:          opts.Logger,
+		config:          opts.Config,
+		puzzleCache:     opts.PuzzleCache,
+		resourceCache:   opts.ResourceCache,
+		errorChecker:    opts.ErrorChecker,
+		puzzlePolicy:    opts.PuzzlePolicy,
+		failureReporter: opts.FailureReporter,
+		resourceBinder:  opts.ResourceBinder,
+		resourceHandler: opts.ResourceHandler,
+		tracer:          opts.Tracer,
 	}
 }
 
 // Server - server-side service
 type Server struct {
-	logger        Logger
-	config        ServerConfig
-	puzzleCache   PuzzleCache
-	resourceCache ResourceCache
-	errorChecker  ErrorChecker
+	logger          Logger
+	config          ServerConfig
+	puzzleCache     PuzzleCache
+	resourceCache   ResourceCache
+	errorChecker    ErrorChecker
+	puzzlePolicy    PuzzlePolicy
+	failureReporter FailureReporter
+	resourceBinder  ResourceBinder
+	resourceHandler ResourceHandler
+	tracer          trace.Tracer
+
+	isDraining atomic.Bool
+}
+
+// Drain - stop issuing new puzzles
+// Already issued puzzles can still be redeemed
+func (s *Server) Drain() {
+	s.isDraining.Store(true)
+}
+
+// Reject - notify client that connection is rejected
+func (s *Server) Reject(ctx context.Context, clientID string, reason error, w io.Writer) {
+	s.writeError(ctx, clientID, reason, w)
 }
 
 // HandleMessages - handle client messages
-func (s *Server) HandleMessages(clientID string, rw io.ReadWriter) {
+// Returns when client got a resource, an error occurred or context is done
+// Returns true if client passed proof of work and got a resource
+func (s *Server) HandleMessages(ctx context.Context, clientID string, rw io.ReadWriter) (passed bool) {
 	const op = "service.Server.HandleMessages"
 
+	ctx, span := s.tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("client.id", clientID)),
+	)
+	defer span.End()
+
 	s.logger.Info("connected new client", "clientID", clientID)
 
+	var (
+		apiKey       string
+		proofSkipped bool
+	)
+
 	for {
-		rawMsg, err := bufio.NewReader(rw).ReadString(message.DelimiterMessage)
+		if err := ctx.Err(); err != nil {
+			s.handleContextDone(ctx, clientID, rw)
+			return
+		}
+
+		rawMsg, err := message.ReadMessage(rw)
 		if err != nil {
+			if ctx.Err() != nil {
+				s.handleContextDone(ctx, clientID, rw)
+				return
+			}
+
+			if errors.Is(err, message.ErrMessageTooLarge) {
+				s.logger.Info(ErrMessageTooLarge.Error(), "clientID", clientID)
+				s.writeError(ctx, clientID, ErrMessageTooLarge, rw)
+				s.reportFailure(ctx, clientID)
+				return
+			}
+
 			clientErr := ErrInternalError
 			if s.errorChecker.IsTimeout(err) {
 				clientErr = ErrTimeoutExceeded
@@ -56,7 +120,7 @@ func (s *Server) HandleMessages(clientID string, rw io.ReadWriter) {
 			} else {
 				s.logger.Error(err.Error(), "op", op, "clientID", clientID)
 			}
-			s.writeError(clientID, clientErr, rw)
+			s.writeError(ctx, clientID, clientErr, rw)
 
 			return
 		}
@@ -64,32 +128,104 @@ func (s *Server) HandleMessages(clientID string, rw io.ReadWriter) {
 		msg, err := message.ParseMessage(rawMsg)
 		if err != nil {
 			s.logger.Info(ErrIncorrectMessageFormat.Error(), "clientID", clientID, "message", rawMsg)
-			s.writeError(clientID, ErrIncorrectMessageFormat, rw)
+			s.writeError(ctx, clientID, ErrIncorrectMessageFormat, rw)
 			return
 		}
 
 		switch msg.Command {
+		case message.CommandRequestHandshake:
+			apiKey = msg.Payload
+			s.responseHandshake(ctx, clientID, rw)
 		case message.CommandRequestPuzzle:
-			s.responsePuzzle(clientID, msg.Payload, rw)
+			if s.isDraining.Load() {
+				s.logger.Info(ErrServerShuttingDown.Error(), "clientID", clientID)
+				s.writeError(ctx, clientID, ErrServerShuttingDown, rw)
+				return
+			}
+			proofSkipped = s.responsePuzzle(ctx, clientID, apiKey, msg, rw)
 		case message.CommandRequestResource:
-			s.responseResource(clientID, msg.Payload, rw)
-			return
+			return s.responseResource(ctx, clientID, proofSkipped, msg, rw)
 		default:
-			s.writeError(clientID, ErrIncorrectMessageFormat, rw)
+			s.writeError(ctx, clientID, ErrIncorrectMessageFormat, rw)
 			return
 		}
 	}
 }
 
-func (s *Server) responsePuzzle(clientID string, payload string, w io.Writer) {
+// handleContextDone - notify client that handling was interrupted by context
+func (s *Server) handleContextDone(ctx context.Context, clientID string, w io.Writer) {
+	clientErr := ErrRequestCanceled
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		clientErr = ErrTimeoutExceeded
+	}
+
+	s.logger.Info(clientErr.Error(), "clientID", clientID)
+	s.writeError(ctx, clientID, clientErr, w)
+}
+
+func (s *Server) responseHandshake(ctx context.Context, clientID string, w io.Writer) {
+	s.logger.Info("handshake received", "clientID", clientID)
+
+	msg := message.Message{
+		Command: message.CommandResponseHandshake,
+	}
+
+	s.writeMsg(clientID, msg, w)
+}
+
+// responsePuzzle - send new puzzle to client
+// Sends empty puzzle and returns true if puzzle policy lets client skip proof of work
+func (s *Server) responsePuzzle(ctx context.Context, clientID string, apiKey string, reqMsg message.Message, w io.Writer) (proofSkipped bool) {
 	const op = "service.Server.responsePuzzle"
 
+	ctx, span := s.startStepSpan(ctx, op, reqMsg)
+	defer span.End()
+
 	s.logger.Info("requested new puzzle", "clientID", clientID)
 
-	hashcash, err := hashcash.New(s.config.PuzzleZeroBits(), clientID)
+	// Puzzle with target is issued if difficulty is set in attempts,
+	// policy compares zero bits of the closest harder puzzle with its own
+	bits := s.config.PuzzleZeroBits()
+	attempts := s.config.PuzzleAttempts()
+	if listenerBits, ok := difficulty.FromContext(ctx); ok {
+		bits = listenerBits
+		attempts = 0
+	}
+	if attempts > 0 {
+		bits = zeroBitsAbove(attempts)
+	}
+
+	client, _ := peer.FromContext(ctx)
+	if policyBits := s.puzzlePolicy.PuzzleZeroBits(client, apiKey, bits); policyBits != bits {
+		bits = policyBits
+		attempts = 0
+	}
+	span.SetAttributes(attribute.Int("puzzle.zero_bits", bits))
+	if attempts > 0 {
+		span.SetAttributes(attribute.Float64("puzzle.attempts", attempts))
+	}
+
+	if bits <= 0 {
+		msg := message.Message{
+			Command: message.CommandResponsePuzzle,
+		}
+
+		s.writeMsg(clientID, msg, w)
+		s.logger.Info("proof of work skipped for trusted client", "clientID", clientID)
+		return true
+	}
+
+	resource, err := s.resourceBinder.Resource(clientID, reqMsg.Payload)
+	if err != nil {
+		s.logger.Info(err.Error(), "clientID", clientID, "identity", reqMsg.Payload)
+		s.writeError(ctx, clientID, err, w)
+		return
+	}
+
+	hashcash, err := newPuzzle(bits, attempts, resource)
 	if err != nil {
 		s.logger.Error(err.Error(), "op", op, "clientID", clientID)
-		s.writeError(clientID, ErrInternalError, w)
+		s.writeError(ctx, clientID, ErrInternalError, w)
 		return
 	}
 
@@ -102,53 +238,106 @@ func (s *Server) responsePuzzle(clientID string, payload string, w io.Writer) {
 	}
 
 	s.writeMsg(clientID, msg, w)
+	span.SetAttributes(attribute.String("puzzle", msg.Payload))
 	s.logger.Info("puzzle sent", "clientID", clientID, "puzzle", msg.Payload)
+
+	return
 }
 
-func (s *Server) responseResource(clientID string, payload string, w io.Writer) {
+// responseResource - send resource to client if solved puzzle is correct
+// Puzzle is not checked if proof of work was skipped
+// Returns true if resource was sent
+func (s *Server) responseResource(ctx context.Context, clientID string, proofSkipped bool, reqMsg message.Message, w io.Writer) (passed bool) {
 	const op = "service.Server.responseResource"
 
+	ctx, span := s.startStepSpan(ctx, op, reqMsg)
+	defer span.End()
+
+	if proofSkipped {
+		s.logger.Info("requested resource by trusted client", "clientID", clientID)
+		return s.sendResource(ctx, clientID, w)
+	}
+
+	payload := reqMsg.Payload
+	span.SetAttributes(attribute.String("solution", payload))
+
 	s.logger.Info("requested resource", "clientID", clientID, "solution", payload)
 
 	hashcash, err := hashcash.ParseHeader(payload)
 	if err != nil {
 		s.logger.Info(ErrHashcashHeaderNotCorrect.Error(), "clientID", clientID, "header", payload)
-		s.writeError(clientID, ErrHashcashHeaderNotCorrect, w)
+		s.writeError(ctx, clientID, ErrHashcashHeaderNotCorrect, w)
+		s.reportFailure(ctx, clientID)
 		return
 	}
 
-	if _, ok := s.puzzleCache.Get(hashcash.Key()); !ok {
+	resource, err := s.resourceBinder.Resource(clientID, hashcash.Resource())
+	if err != nil || !hashcash.EqualResource(resource) {
 		s.logger.Info(ErrHashcashHeaderNotFound.Error(), "clientID", clientID, "header", payload)
-		s.writeError(clientID, ErrHashcashHeaderNotFound, w)
-		return
-	}
-	if !hashcash.EqualResource(clientID) {
-		s.logger.Info(ErrHashcashHeaderNotFound.Error(), "clientID", clientID, "header", payload)
-		s.writeError(clientID, ErrHashcashHeaderNotFound, w)
+		s.writeError(ctx, clientID, ErrHashcashHeaderNotFound, w)
+		s.reportFailure(ctx, clientID)
 		return
 	}
 	if !hashcash.IsActual(s.config.PuzzleTTL()) {
 		s.logger.Info(ErrHashcashExpirationExceeded.Error(), "clientID", clientID, "header", payload)
-		s.writeError(clientID, ErrHashcashExpirationExceeded, w)
+		s.writeError(ctx, clientID, ErrHashcashExpirationExceeded, w)
+		s.reportFailure(ctx, clientID)
 		return
 	}
 
-	isHashCorrect, err := hashcash.Header().IsHashCorrect(hashcash.Bits())
-	if err != nil {
-		s.logger.Error(err.Error(), "op", op, "clientID", clientID)
-		s.writeError(clientID, ErrInternalError, w)
+	// Hash of forged header can't be checked, e.g. it has incorrect bits, so it's not correct too
+	isHashCorrect, err := hashcash.IsSolved()
+	if err != nil || !isHashCorrect {
+		s.logger.Info(ErrHashcashHeaderNotCorrect.Error(), "clientID", clientID, "header", payload)
+		s.writeError(ctx, clientID, ErrHashcashHeaderNotCorrect, w)
+		s.reportFailure(ctx, clientID)
 		return
 	}
-	if !isHashCorrect {
-		s.logger.Info(ErrHashcashHeaderNotCorrect.Error(), "clientID", clientID, "header", payload)
-		s.writeError(clientID, ErrHashcashHeaderNotCorrect, w)
+
+	// Puzzle is taken atomically after checks, so concurrent requests can't spend solution twice
+	if !s.puzzleCache.Take(hashcash.Key()) {
+		s.logger.Info(ErrHashcashHeaderNotFound.Error(), "clientID", clientID, "header", payload)
+		s.writeError(ctx, clientID, ErrHashcashHeaderNotFound, w)
+		s.reportFailure(ctx, clientID)
 		return
 	}
 
-	resource, err := s.randomResource()
+	return s.sendResource(ctx, clientID, w)
+}
+
+// newPuzzle - create puzzle with target if attempts is set, otherwise with zero bits
+func newPuzzle(bits int, attempts float64, resource string) (*hashcash.Hashcash, error) {
+	if attempts > 0 {
+		return hashcash.NewTarget(attempts, resource)
+	}
+	return hashcash.New(bits, resource)
+}
+
+// zeroBitsAbove - returns min number of zero bits, which puzzle is not easier than attempts
+func zeroBitsAbove(attempts float64) int {
+	bits := 1
+	for bits < hashcash.MaxBits && hashcash.ExpectedAttempts(bits) < attempts {
+		bits++
+	}
+	return bits
+}
+
+// reportFailure - report client which submitted invalid or expired solution
+func (s *Server) reportFailure(ctx context.Context, clientID string) {
+	client, _ := peer.FromContext(ctx)
+
+	if s.failureReporter.ReportFailure(client.IP()) {
+		s.logger.Info("client banned", "clientID", clientID)
+	}
+}
+
+func (s *Server) sendResource(ctx context.Context, clientID string, w io.Writer) (sent bool) {
+	const op = "service.Server.sendResource"
+
+	resource, err := s.resource(ctx, clientID)
 	if err != nil {
 		s.logger.Error(err.Error(), "op", op, "clientID", clientID)
-		s.writeError(clientID, ErrInternalError, w)
+		s.writeError(ctx, clientID, ErrInternalError, w)
 		return
 	}
 
@@ -157,9 +346,36 @@ func (s *Server) responseResource(clientID string, payload string, w io.Writer)
 		Payload: resource,
 	}
 
-	s.writeMsg(clientID, msg, w)
-	s.puzzleCache.Delete(hashcash.Key())
+	if !s.writeMsg(clientID, msg, w) {
+		return false
+	}
+
 	s.logger.Info("resource sent", "clientID", clientID, "resource", msg.Payload)
+	return true
+}
+
+// startStepSpan - start span of protocol step
+// If request message carries client trace context the span continues client trace
+// and links to connection span, otherwise it is a child of connection span
+func (s *Server) startStepSpan(ctx context.Context, name string, reqMsg message.Message) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer)}
+
+	if s.config.TracePropagation() {
+		if remote := extractTrace(reqMsg); remote.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.LinkFromContext(ctx)))
+			ctx = trace.ContextWithRemoteSpanContext(ctx, remote)
+		}
+	}
+
+	return s.tracer.Start(ctx, name, opts...)
+}
+
+// resource - returns resource of handler if it's set or random resource
+func (s *Server) resource(ctx context.Context, clientID string) (string, error) {
+	if s.resourceHandler != nil {
+		return s.resourceHandler.HandleResource(ctx, clientID)
+	}
+	return s.randomResource()
 }
 
 func (s *Server) randomResource() (string, error) {
@@ -179,17 +395,21 @@ func (s *Server) randomResource() (string, error) {
 	return resource, nil
 }
 
-func (s *Server) writeMsg(clientID string, msg message.Message, w io.Writer) {
+func (s *Server) writeMsg(clientID string, msg message.Message, w io.Writer) (written bool) {
 	const op = "service.Server.writeMsg"
 
 	if _, err := w.Write(msg.Bytes()); err != nil {
 		s.logger.Error(err.Error(), "op", op, "clientID", clientID)
+		return false
 	}
+	return true
 }
 
-func (s *Server) writeError(clientID string, handleErr error, w io.Writer) {
+func (s *Server) writeError(ctx context.Context, clientID string, handleErr error, w io.Writer) {
 	const op = "service.Server.writeError"
 
+	recordError(ctx, handleErr)
+
 	if _, err := w.Write(errorMessage(handleErr).Bytes()); err != nil {
 		s.logger.Error(err.Error(), "op", op, "clientID", clientID)
 	}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceParentKey = "traceparent"
+
+var tracePropagator = propagation.TraceContext{}
+
+// injectTrace - put span context of ctx into message trace field
+func injectTrace(ctx context.Context, msg *message.Message) {
+	tracePropagator.Inject(ctx, messageCarrier{msg: msg})
+}
+
+// extractTrace - get remote span context from message trace field
+func extractTrace(msg message.Message) trace.SpanContext {
+	ctx := tracePropagator.Extract(context.Background(), messageCarrier{msg: &msg})
+	return trace.SpanContextFromContext(ctx)
+}
+
+// recordError - mark span of ctx as failed
+func recordError(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// messageCarrier - adapts message trace field to propagation.TextMapCarrier
+// Only traceparent is transferred, tracestate is dropped
+type messageCarrier struct {
+	msg *message.Message
+}
+
+func (c messageCarrier) Get(key string) string {
+	if key == traceParentKey {
+		return c.msg.Trace
+	}
+	return ""
+}
+
+func (c messageCarrier) Set(key string, value string) {
+	if key == traceParentKey {
+		c.msg.Trace = value
+	}
+}
+
+func (c messageCarrier) Keys() []string {
+	return []string{traceParentKey}
+}
@@ -0,0 +1,138 @@
+// Package client - client of powtcp server
+//
+// Client connects to server, solves proof of work puzzle and returns resource:
+//
+//	c, err := client.New("127.0.0.1:8080")
+//	resource, err := c.Fetch(ctx)
+package client
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/app/client"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/retry"
+	"github.com/pvarentsov/powtcp/internal/pkg/service"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var networks = map[string]struct{}{
+	"tcp":  {},
+	"tcp4": {},
+	"tcp6": {},
+	"unix": {},
+	"ws":   {},
+}
+
+// New - create new client of server address
+func New(address string, opts ...Option) (*Client, error) {
+	o := options{
+		network:     "tcp",
+		maxAttempts: defaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if address == "" {
+		return nil, ErrAddressRequired
+	}
+	if _, ok := networks[o.network]; !ok {
+		return nil, ErrUnknownNetwork
+	}
+	if o.maxAttempts <= 0 {
+		o.maxAttempts = defaultMaxAttempts
+	}
+	if o.logger == nil {
+		o.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if o.tracer == nil {
+		o.tracer = noop.NewTracerProvider().Tracer("")
+	}
+
+	config := &config{address: address, options: o}
+
+	var retryPolicy client.Retry
+	if o.retryAttempts > 1 {
+		retryable, _ := client.Retryable(client.DefaultRetryCategories)
+		retryPolicy = retry.New(retry.Opts{
+			MaxAttempts:    o.retryAttempts,
+			InitialBackoff: o.retryBackoff,
+			MaxBackoff:     o.retryMaxBackoff,
+			Jitter:         defaultRetryJitter,
+			Retryable:      retryable,
+			Logger:         o.logger,
+		})
+	}
+
+	return &Client{
+		config: config,
+		retry:  retryPolicy,
+		service: service.NewClient(service.ClientOpts{
+			Logger: o.logger,
+			Config: config,
+			Tracer: o.tracer,
+		}),
+	}, nil
+}
+
+// Client - client of powtcp server
+// Client is safe for concurrent use, each Fetch uses its own connection
+type Client struct {
+	config  *config
+	service *service.Client
+	retry   client.Retry
+}
+
+// Fetch - connect to server, solve puzzle and return resource
+// Context cancellation interrupts dialing and solving.
+// Errors sent by server are *ServerError
+func (c *Client) Fetch(ctx context.Context) (string, error) {
+	return client.Connect(ctx, client.Opts{
+		Config:    c.config,
+		Logger:    c.config.logger,
+		Service:   c.service,
+		TLSConfig: c.config.tlsConfig,
+		Retry:     c.retry,
+	})
+}
+
+// config - adapter of options to app and service config interfaces
+type config struct {
+	address string
+	options
+}
+
+func (c *config) ServerAddress() string {
+	return c.address
+}
+
+func (c *config) ServerNetwork() string {
+	return c.network
+}
+
+func (c *config) PuzzleComputeMaxAttempts() int {
+	return c.maxAttempts
+}
+
+func (c *config) PuzzleMaxZeroBits() int {
+	return c.maxBits
+}
+
+func (c *config) PuzzleSolveBudget() time.Duration {
+	return c.solveBudget
+}
+
+func (c *config) APIKey() string {
+	return c.apiKey
+}
+
+func (c *config) Identity() string {
+	return c.identity
+}
+
+func (c *config) TracePropagation() bool {
+	return c.tracePropagation
+}
@@ -0,0 +1,313 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/app/server"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/binding"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cache"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/ipfilter"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/tcp"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/trust"
+	"github.com/pvarentsov/powtcp/internal/pkg/service"
+	"github.com/pvarentsov/powtcp/pkg/client"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const testResource = "Don't Panic."
+
+type testConfig struct {
+	bits           int
+	attempts       float64
+	maxConnections int
+	apiKeys        []string
+	banThreshold   int
+}
+
+func (c testConfig) Address() string                       { return "127.0.0.1:0" }
+func (c testConfig) Listeners() []server.ListenerConfig    { return nil }
+func (c testConfig) ShutdownTimeout() time.Duration        { return time.Second }
+func (c testConfig) ConnectionTimeout() time.Duration      { return 5 * time.Second }
+func (c testConfig) MaxConnections() int                   { return c.maxConnections }
+func (c testConfig) MaxConnectionsPerIP() int              { return 0 }
+func (c testConfig) ConnectionQueueSize() int              { return 0 }
+func (c testConfig) ConnectionQueueTimeout() time.Duration { return 0 }
+func (c testConfig) ProxyProtocol() bool                   { return false }
+func (c testConfig) TrustedProxyCIDRs() []string           { return nil }
+func (c testConfig) BackendAddress() string                { return "" }
+func (c testConfig) PuzzleTTL() time.Duration              { return time.Minute }
+func (c testConfig) PuzzleZeroBits() int                   { return c.bits }
+func (c testConfig) PuzzleAttempts() float64               { return c.attempts }
+func (c testConfig) TracePropagation() bool                { return false }
+
+// startServer - start in-process server and returns its address
+func startServer(t *testing.T, config testConfig) (address string, srv *server.Server) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	resourceCache := cache.New[int, string](ctx, cache.Opts{})
+	resourceCache.Add(0, testResource)
+
+	trustPolicy, err := trust.New(trust.Opts{APIKeys: config.apiKeys})
+	require.NoError(t, err)
+
+	ipFilter, err := ipfilter.New(ctx, ipfilter.Opts{
+		BanThreshold: config.banThreshold,
+		BanWindow:    time.Minute,
+		BanDuration:  time.Minute,
+		Logger:       logger,
+	})
+	require.NoError(t, err)
+
+	binder, err := binding.New(binding.Opts{Policy: binding.PolicyAddress})
+	require.NoError(t, err)
+
+	service := service.NewServer(service.ServerOpts{
+		Logger:          logger,
+		Config:          config,
+		PuzzleCache:     cache.New[string, struct{}](ctx, cache.Opts{}),
+		ResourceCache:   resourceCache,
+		ErrorChecker:    tcp.NewConnErrorChecker(),
+		PuzzlePolicy:    trustPolicy,
+		FailureReporter: ipFilter,
+		ResourceBinder:  binder,
+		Tracer:          noop.NewTracerProvider().Tracer(""),
+	})
+
+	srv, err = server.Listen(ctx, server.Opts{
+		Config:   config,
+		Logger:   logger,
+		Service:  service,
+		IPFilter: ipFilter,
+	})
+	require.NoError(t, err)
+	t.Cleanup(srv.Shutdown)
+
+	return strings.TrimPrefix(srv.Addrs()[0], "tcp://"), srv
+}
+
+func Test_New(t *testing.T) {
+	t.Run("address required", func(t *testing.T) {
+		_, err := client.New("")
+		require.ErrorIs(t, err, client.ErrAddressRequired)
+	})
+
+	t.Run("unknown network", func(t *testing.T) {
+		_, err := client.New("127.0.0.1:8080", client.WithNetwork("udp"))
+		require.ErrorIs(t, err, client.ErrUnknownNetwork)
+	})
+}
+
+func Test_Fetch(t *testing.T) {
+	t.Run("fetch ok", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 2})
+
+		c, err := client.New(address)
+		require.NoError(t, err)
+
+		resource, err := c.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, testResource, resource)
+	})
+
+	t.Run("fetch puzzle with target ok", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 1, attempts: 1000})
+
+		c, err := client.New(address)
+		require.NoError(t, err)
+
+		resource, err := c.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, testResource, resource)
+	})
+
+	t.Run("fetch by trusted client ok", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 10, apiKeys: []string{"secret"}})
+
+		c, err := client.New(address, client.WithAPIKey("secret"))
+		require.NoError(t, err)
+
+		resource, err := c.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, testResource, resource)
+	})
+
+	t.Run("max attempts exceeded", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 6})
+
+		c, err := client.New(address, client.WithMaxAttempts(1))
+		require.NoError(t, err)
+
+		_, err = c.Fetch(context.Background())
+		require.ErrorIs(t, err, client.ErrMaxAttemptsExceeded)
+	})
+
+	t.Run("fetch canceled", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 10})
+
+		c, err := client.New(address)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = c.Fetch(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("server closed", func(t *testing.T) {
+		address, srv := startServer(t, testConfig{bits: 2})
+
+		c, err := client.New(address)
+		require.NoError(t, err)
+
+		srv.Shutdown()
+
+		_, err = c.Fetch(context.Background())
+		require.Error(t, err)
+	})
+
+	t.Run("server error typed", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 10, maxConnections: 1})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		busy, err := client.New(address)
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			busy.Fetch(ctx)
+			close(done)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		c, err := client.New(address)
+		require.NoError(t, err)
+
+		_, err = c.Fetch(context.Background())
+		require.ErrorIs(t, err, client.ErrTooManyConnections)
+
+		var serverErr *client.ServerError
+		require.True(t, errors.As(err, &serverErr))
+		require.Equal(t, client.ErrTooManyConnections.Error(), serverErr.Message)
+
+		cancel()
+		<-done
+	})
+	t.Run("forged header banned", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 10, banThreshold: 1})
+
+		conn, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		send := func(msg message.Message) message.Message {
+			_, err := conn.Write(msg.Bytes())
+			require.NoError(t, err)
+
+			raw, err := message.ReadMessage(conn)
+			require.NoError(t, err)
+			reply, err := message.ParseMessage(raw)
+			require.NoError(t, err)
+			return reply
+		}
+
+		puzzle := send(message.Message{Command: message.CommandRequestPuzzle})
+		require.Equal(t, message.CommandResponsePuzzle, puzzle.Command)
+
+		// Zero bits header of issued puzzle can't be checked
+		parts := strings.SplitN(puzzle.Payload, ":", 3)
+		forged := parts[0] + ":0:" + parts[2]
+
+		reply := send(message.Message{Command: message.CommandRequestResource, Payload: forged})
+		require.Equal(t, message.CommandError, reply.Command)
+		require.Equal(t, service.ErrHashcashHeaderNotCorrect.Error(), reply.Payload)
+
+		c, err := client.New(address)
+		require.NoError(t, err)
+
+		_, err = c.Fetch(context.Background())
+		var serverErr *client.ServerError
+		require.True(t, errors.As(err, &serverErr), err)
+		require.Equal(t, ipfilter.ErrBanned.Error(), serverErr.Message)
+	})
+
+	t.Run("fetch retried while server is busy", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 2, maxConnections: 1})
+
+		busy, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+		time.AfterFunc(100*time.Millisecond, func() { busy.Close() })
+
+		c, err := client.New(address, client.WithRetry(20, 20*time.Millisecond, 50*time.Millisecond))
+		require.NoError(t, err)
+
+		resource, err := c.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, testResource, resource)
+	})
+
+	t.Run("fetch not retried on not retryable error", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 6})
+
+		c, err := client.New(address, client.WithMaxAttempts(1), client.WithRetry(3, time.Minute, time.Minute))
+		require.NoError(t, err)
+
+		_, err = c.Fetch(context.Background())
+		require.ErrorIs(t, err, client.ErrMaxAttemptsExceeded)
+	})
+	t.Run("puzzle too difficult", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 6})
+
+		c, err := client.New(address, client.WithMaxBits(5))
+		require.NoError(t, err)
+
+		_, err = c.Fetch(context.Background())
+		require.ErrorIs(t, err, client.ErrPuzzleTooDifficult)
+	})
+
+	t.Run("puzzle with target too difficult", func(t *testing.T) {
+		// 17 attempts is more than 16 attempts of 1 zero bit
+		address, _ := startServer(t, testConfig{bits: 1, attempts: 17})
+
+		c, err := client.New(address, client.WithMaxBits(1))
+		require.NoError(t, err)
+
+		_, err = c.Fetch(context.Background())
+		require.ErrorIs(t, err, client.ErrPuzzleTooDifficult)
+	})
+
+	t.Run("puzzle solve budget exceeded", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 12})
+
+		c, err := client.New(address, client.WithSolveBudget(time.Second))
+		require.NoError(t, err)
+
+		_, err = c.Fetch(context.Background())
+		require.ErrorIs(t, err, client.ErrSolveBudgetExceeded)
+	})
+
+	t.Run("puzzle within solve budget", func(t *testing.T) {
+		address, _ := startServer(t, testConfig{bits: 2})
+
+		c, err := client.New(address, client.WithMaxBits(5), client.WithSolveBudget(time.Second))
+		require.NoError(t, err)
+
+		resource, err := c.Fetch(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, testResource, resource)
+	})
+}
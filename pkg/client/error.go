@@ -0,0 +1,44 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/binding"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/ipfilter"
+	"github.com/pvarentsov/powtcp/internal/pkg/service"
+)
+
+// ServerError - error received from server
+// Matches errors below with the same text, so use errors.Is to check them
+type ServerError = service.ServerError
+
+// Errors of client options
+var (
+	ErrAddressRequired = errors.New("server address is required")
+	ErrUnknownNetwork  = errors.New("unknown network")
+)
+
+// Errors sent by server
+var (
+	ErrServerShuttingDown       = service.ErrServerShuttingDown
+	ErrTimeoutExceeded          = service.ErrTimeoutExceeded
+	ErrMessageTooLarge          = service.ErrMessageTooLarge
+	ErrTooManyConnections       = service.ErrTooManyConnections
+	ErrTooManyConnectionsFromIP = service.ErrTooManyConnectionsFromIP
+	ErrIPDenied                 = ipfilter.ErrDenied
+	ErrIPNotAllowed             = ipfilter.ErrNotAllowed
+	ErrIPBanned                 = ipfilter.ErrBanned
+	ErrIdentityRequired         = binding.ErrIdentityRequired
+	ErrPuzzleExpired            = service.ErrHashcashExpirationExceeded
+	ErrPuzzleNotFound           = service.ErrHashcashHeaderNotFound
+	ErrSolutionNotCorrect       = service.ErrHashcashHeaderNotCorrect
+)
+
+// Errors of client side protocol steps
+var (
+	ErrMaxAttemptsExceeded = hashcash.ErrComputingMaxAttemptsExceeded
+	ErrUnexpectedResponse  = service.ErrResponseCommandNotcorrect
+	ErrPuzzleTooDifficult  = service.ErrPuzzleTooDifficult
+	ErrSolveBudgetExceeded = service.ErrPuzzleSolveBudgetExceeded
+)
@@ -0,0 +1,70 @@
+package client_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/pvarentsov/powtcp/pkg/client"
+)
+
+func ExampleClient_Fetch() {
+	c, err := client.New("127.0.0.1:8080")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resource, err := c.Fetch(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(resource)
+}
+
+func ExampleNew() {
+	c, err := client.New("wss://pow.example.com/pow",
+		client.WithNetwork("ws"),
+		client.WithTLS(&tls.Config{MinVersion: tls.VersionTLS12}),
+		client.WithAPIKey(os.Getenv("POWTCP_API_KEY")),
+		client.WithMaxAttempts(10000000),
+		client.WithLogger(slog.Default()),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	_ = c
+}
+
+func Example_errors() {
+	c, err := client.New("127.0.0.1:8080")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	_, err = c.Fetch(context.Background())
+
+	var serverErr *client.ServerError
+	switch {
+	case errors.Is(err, client.ErrTooManyConnections), errors.Is(err, client.ErrServerShuttingDown):
+		fmt.Println("server is busy, try later")
+	case errors.Is(err, client.ErrMaxAttemptsExceeded):
+		fmt.Println("puzzle is too hard")
+	case errors.As(err, &serverErr):
+		fmt.Println("rejected by server:", serverErr.Message)
+	case err != nil:
+		fmt.Println(err)
+	}
+}
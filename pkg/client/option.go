@@ -0,0 +1,111 @@
+package client
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultMaxAttempts = 100000000
+	defaultRetryJitter = 0.2
+)
+
+// Option - client option
+type Option func(*options)
+
+type options struct {
+	network          string
+	tlsConfig        *tls.Config
+	apiKey           string
+	identity         string
+	maxAttempts      int
+	maxBits          int
+	solveBudget      time.Duration
+	logger           *slog.Logger
+	tracer           trace.Tracer
+	tracePropagation bool
+	retryAttempts    int
+	retryBackoff     time.Duration
+	retryMaxBackoff  time.Duration
+}
+
+// WithNetwork - set server network: tcp (default), tcp4, tcp6, unix or ws
+// Address is a socket path for unix and ws:// or wss:// url for ws
+func WithNetwork(network string) Option {
+	return func(o *options) {
+		o.network = network
+	}
+}
+
+// WithTLS - connect to server over tls, websocket uses it for wss urls only
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithAPIKey - send api key in handshake to be trusted by server
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithIdentity - send identity with puzzle request, required by "client" puzzle binding
+func WithIdentity(identity string) Option {
+	return func(o *options) {
+		o.identity = identity
+	}
+}
+
+// WithMaxAttempts - set max attempts to solve puzzle, defaultMaxAttempts if value <= 0
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(o *options) {
+		o.maxAttempts = maxAttempts
+	}
+}
+
+// WithMaxBits - refuse puzzles with more zero bits with ErrPuzzleTooDifficult, disabled if value <= 0
+func WithMaxBits(bits int) Option {
+	return func(o *options) {
+		o.maxBits = bits
+	}
+}
+
+// WithSolveBudget - refuse puzzles with ErrPuzzleSolveBudgetExceeded
+// if their solve time estimated from local hash rate exceeds budget, disabled if value <= 0.
+// Hash rate is measured once on first puzzle, it takes a fraction of a second
+func WithSolveBudget(budget time.Duration) Option {
+	return func(o *options) {
+		o.solveBudget = budget
+	}
+}
+
+// WithLogger - set logger, logs are discarded by default
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithTracer - set tracer, propagate - send trace context to server
+func WithTracer(tracer trace.Tracer, propagate bool) Option {
+	return func(o *options) {
+		o.tracer = tracer
+		o.tracePropagation = propagate
+	}
+}
+
+// WithRetry - retry up to attempts times in total with a new connection and a fresh puzzle
+// if puzzle expired, server timed out or is busy, or connection failed.
+// Delay starts from backoff and doubles for every retry up to maxBackoff, it's randomized by 20%
+func WithRetry(attempts int, backoff time.Duration, maxBackoff time.Duration) Option {
+	return func(o *options) {
+		o.retryAttempts = attempts
+		o.retryBackoff = backoff
+		o.retryMaxBackoff = maxBackoff
+	}
+}
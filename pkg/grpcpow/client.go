@@ -0,0 +1,69 @@
+package grpcpow
+
+import (
+	"context"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/puzzle"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ChallengeFromError - returns puzzle of challenge error
+func ChallengeFromError(err error) (challenge string, ok bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != ChallengeCode {
+		return "", false
+	}
+
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.GetReason() != ErrorReason || info.GetDomain() != ErrorDomain {
+			continue
+		}
+		if challenge = info.GetMetadata()[ChallengeKey]; challenge != "" {
+			return challenge, true
+		}
+	}
+
+	return "", false
+}
+
+// Solve - solve puzzle of challenge error
+// maxAttempts - max attempts to compute hashcash, puzzle default if value <= 0
+func Solve(ctx context.Context, challenge string, maxAttempts int) (stamp string, err error) {
+	return puzzle.Solve(ctx, challenge, maxAttempts)
+}
+
+// ContextWithStamp - returns outgoing context with solved puzzle
+// Stream clients use it to repeat call after challenge error
+// Stamp replaces one the context already has, e.g. context of previous attempt
+func ContextWithStamp(ctx context.Context, stamp string) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(MetadataKey, stamp)
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// UnaryClientInterceptor - returns unary client interceptor
+// which solves challenges and repeats calls transparently
+// maxAttempts - max attempts to compute hashcash, puzzle default if value <= 0
+func UnaryClientInterceptor(maxAttempts int) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		challenge, ok := ChallengeFromError(err)
+		if !ok {
+			return err
+		}
+
+		stamp, err := Solve(ctx, challenge, maxAttempts)
+		if err != nil {
+			return err
+		}
+
+		return invoker(ContextWithStamp(ctx, stamp), method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,203 @@
+// Package grpcpow - proof of work protection of grpc methods
+//
+// Server interceptors challenge calls with puzzles, client interceptor solves them:
+//
+//	interceptor, err := grpcpow.New(grpcpow.Opts{
+//		Bits:        5,
+//		TTL:         time.Minute,
+//		PuzzleCache: grpcpow.NewPuzzleCache(ctx, time.Minute),
+//	})
+//	server := grpc.NewServer(
+//		grpc.UnaryInterceptor(interceptor.Unary()),
+//		grpc.StreamInterceptor(interceptor.Stream()),
+//	)
+//
+//	conn, err := grpc.Dial(address, grpc.WithUnaryInterceptor(grpcpow.UnaryClientInterceptor(0)), ...)
+package grpcpow
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cache"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/puzzle"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcpeer "google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MetadataKey - request metadata key with solved puzzle
+	MetadataKey = "x-hashcash"
+
+	// ErrorReason, ErrorDomain - identify challenge error detail
+	ErrorReason = "HASHCASH_CHALLENGE"
+	ErrorDomain = "powtcp"
+
+	// ChallengeKey - key of puzzle in challenge error detail metadata
+	ChallengeKey = "challenge"
+)
+
+// ChallengeCode - status code of calls without valid stamp
+const ChallengeCode = codes.ResourceExhausted
+
+// Logger - logger interface
+type Logger interface {
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// PuzzleCache - store of issued puzzles, e.g. shared by several servers
+// Take must atomically delete actual puzzle and report whether it was stored,
+// so solution is accepted once
+type PuzzleCache interface {
+	AddWithExp(k string, v struct{}, exp time.Time)
+	Take(k string) (ok bool)
+}
+
+// NewPuzzleCache - create in-memory puzzle cache
+// Expired puzzles are cleaned every cleanInterval until context is done, if interval > 0
+func NewPuzzleCache(ctx context.Context, cleanInterval time.Duration) PuzzleCache {
+	return cache.New[string, struct{}](ctx, cache.Opts{CleanInterval: cleanInterval})
+}
+
+// Opts - options to create new interceptor
+// Resource - returns resource to bind puzzle to, client ip if nil
+type Opts struct {
+	Bits        int
+	TTL         time.Duration
+	PuzzleCache PuzzleCache
+	Resource    func(ctx context.Context) string
+	Logger      Logger
+}
+
+// New - create new server interceptor
+func New(opts Opts) (*Interceptor, error) {
+	issuer, err := puzzle.New(puzzle.Opts{
+		Bits:        opts.Bits,
+		TTL:         opts.TTL,
+		PuzzleCache: opts.PuzzleCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Interceptor{
+		issuer:   issuer,
+		resource: opts.Resource,
+		logger:   opts.Logger,
+	}
+	if i.resource == nil {
+		i.resource = remoteIP
+	}
+
+	return i, nil
+}
+
+// Interceptor - protects grpc methods with proof of work
+// Call without valid stamp in MetadataKey metadata fails with ChallengeCode status
+// and ErrorInfo detail with puzzle to solve. Client solves puzzle and repeats call
+// with solution, each solution is accepted once
+type Interceptor struct {
+	issuer   *puzzle.Issuer
+	resource func(ctx context.Context) string
+	logger   Logger
+}
+
+// Unary - returns unary server interceptor
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := i.check(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream - returns stream server interceptor
+// Stamp is checked once when stream is opened
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.check(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// check - redeem stamp of call or returns challenge error
+func (i *Interceptor) check(ctx context.Context) error {
+	var stamp string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(MetadataKey); len(values) > 0 {
+			stamp = values[0]
+		}
+	}
+
+	resource := i.resource(ctx)
+
+	err := i.issuer.Redeem(stamp, resource)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, ErrStampRequired) {
+		i.logInfo(err.Error(), "resource", resource, "stamp", stamp)
+	}
+
+	return i.challenge(resource, err)
+}
+
+// challenge - returns error with new puzzle
+func (i *Interceptor) challenge(resource string, reason error) error {
+	const op = "grpcpow.Interceptor.challenge"
+
+	challenge, err := i.issuer.Issue(resource)
+	if err != nil {
+		i.logError(err.Error(), "op", op, "resource", resource)
+		return status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	st, err := status.New(ChallengeCode, reason.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason:   ErrorReason,
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{ChallengeKey: string(challenge)},
+	})
+	if err != nil {
+		i.logError(err.Error(), "op", op, "resource", resource)
+		return status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	return st.Err()
+}
+
+func (i *Interceptor) logInfo(msg string, args ...any) {
+	if i.logger != nil {
+		i.logger.Info(msg, args...)
+	}
+}
+
+func (i *Interceptor) logError(msg string, args ...any) {
+	if i.logger != nil {
+		i.logger.Error(msg, args...)
+	}
+}
+
+// remoteIP - returns ip of grpc peer
+func remoteIP(ctx context.Context) string {
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	addr := p.Addr.String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,153 @@
+package grpcpow
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newHealthClient(t *testing.T, bits int, opts ...grpc.DialOption) healthpb.HealthClient {
+	interceptor, err := New(Opts{
+		Bits:        bits,
+		TTL:         time.Minute,
+		PuzzleCache: NewPuzzleCache(context.Background(), 0),
+	})
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(interceptor.Unary()),
+		grpc.StreamInterceptor(interceptor.Stream()),
+	)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func Test_Unary(t *testing.T) {
+	t.Run("challenge and solve ok", func(t *testing.T) {
+		client := newHealthClient(t, 2)
+		ctx := context.Background()
+
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.Equal(t, ChallengeCode, status.Code(err))
+		require.Equal(t, ErrStampRequired.Error(), status.Convert(err).Message())
+
+		challenge, ok := ChallengeFromError(err)
+		require.True(t, ok)
+
+		stamp, err := Solve(ctx, challenge, 0)
+		require.NoError(t, err)
+
+		resp, err := client.Check(ContextWithStamp(ctx, stamp), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+		_, err = client.Check(ContextWithStamp(ctx, stamp), &healthpb.HealthCheckRequest{})
+		require.Equal(t, ChallengeCode, status.Code(err))
+		require.Equal(t, ErrStampNotFound.Error(), status.Convert(err).Message())
+	})
+
+	t.Run("stamp replaced in context of previous attempt", func(t *testing.T) {
+		client := newHealthClient(t, 2)
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "1")
+
+		solve := func(ctx context.Context) string {
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+			challenge, ok := ChallengeFromError(err)
+			require.True(t, ok)
+
+			stamp, err := Solve(ctx, challenge, 0)
+			require.NoError(t, err)
+			return stamp
+		}
+
+		spentCtx := ContextWithStamp(ctx, solve(ctx))
+		_, err := client.Check(spentCtx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		retryCtx := ContextWithStamp(spentCtx, solve(spentCtx))
+		_, err = client.Check(retryCtx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		md, _ := metadata.FromOutgoingContext(retryCtx)
+		require.Len(t, md.Get(MetadataKey), 1)
+		require.Equal(t, []string{"1"}, md.Get("x-request-id"))
+	})
+
+	t.Run("client interceptor ok", func(t *testing.T) {
+		client := newHealthClient(t, 2, grpc.WithUnaryInterceptor(UnaryClientInterceptor(0)))
+
+		for i := 0; i < 3; i++ {
+			resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+			require.NoError(t, err)
+			require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+		}
+	})
+
+	t.Run("client interceptor max attempts exceeded", func(t *testing.T) {
+		client := newHealthClient(t, 6, grpc.WithUnaryInterceptor(UnaryClientInterceptor(1)))
+
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.ErrorIs(t, err, ErrChallengeNotSolved)
+	})
+}
+
+func Test_Stream(t *testing.T) {
+	t.Run("challenge and solve ok", func(t *testing.T) {
+		client := newHealthClient(t, 2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		challenge, ok := ChallengeFromError(err)
+		require.True(t, ok)
+
+		stamp, err := Solve(ctx, challenge, 0)
+		require.NoError(t, err)
+
+		stream, err = client.Watch(ContextWithStamp(ctx, stamp), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	})
+}
+
+func Test_ChallengeFromError(t *testing.T) {
+	t.Run("not challenge", func(t *testing.T) {
+		_, ok := ChallengeFromError(nil)
+		require.False(t, ok)
+
+		_, ok = ChallengeFromError(status.Error(ChallengeCode, "quota exceeded"))
+		require.False(t, ok)
+	})
+}
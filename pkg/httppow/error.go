@@ -0,0 +1,16 @@
+package httppow
+
+import "github.com/pvarentsov/powtcp/internal/pkg/lib/puzzle"
+
+// Errors
+var (
+	ErrZeroBitsMustBeMoreThanZero = puzzle.ErrZeroBitsMustBeMoreThanZero
+	ErrPuzzleCacheRequired        = puzzle.ErrPuzzleCacheRequired
+
+	ErrStampRequired   = puzzle.ErrStampRequired
+	ErrStampNotCorrect = puzzle.ErrStampNotCorrect
+	ErrStampNotFound   = puzzle.ErrStampNotFound
+	ErrStampExpired    = puzzle.ErrStampExpired
+
+	ErrChallengeNotSolved = puzzle.ErrChallengeNotSolved
+)
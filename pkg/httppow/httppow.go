@@ -0,0 +1,153 @@
+// Package httppow - proof of work protection of net/http handlers
+//
+// Middleware challenges requests with puzzles, Transport solves them:
+//
+//	middleware, err := httppow.New(httppow.Opts{
+//		Bits:        5,
+//		TTL:         time.Minute,
+//		PuzzleCache: httppow.NewPuzzleCache(ctx, time.Minute),
+//	})
+//	http.Handle("/api/", middleware.Handler(apiHandler))
+//
+//	client := &http.Client{Transport: &httppow.Transport{}}
+package httppow
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cache"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/puzzle"
+)
+
+// Headers
+const (
+	// ChallengeHeader - response header with puzzle to solve
+	ChallengeHeader = "X-Hashcash-Challenge"
+
+	// StampHeader - request header with solved puzzle
+	StampHeader = "X-Hashcash"
+)
+
+// ChallengeStatus - response status of requests without valid stamp
+const ChallengeStatus = http.StatusTooManyRequests
+
+// Logger - logger interface
+type Logger interface {
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// PuzzleCache - store of issued puzzles, e.g. shared by several servers
+// Take must atomically delete actual puzzle and report whether it was stored,
+// so solution is accepted once
+type PuzzleCache interface {
+	AddWithExp(k string, v struct{}, exp time.Time)
+	Take(k string) (ok bool)
+}
+
+// NewPuzzleCache - create in-memory puzzle cache
+// Expired puzzles are cleaned every cleanInterval until context is done, if interval > 0
+func NewPuzzleCache(ctx context.Context, cleanInterval time.Duration) PuzzleCache {
+	return cache.New[string, struct{}](ctx, cache.Opts{CleanInterval: cleanInterval})
+}
+
+// Opts - options to create new middleware
+// Resource - returns resource to bind puzzle to, client ip if nil
+type Opts struct {
+	Bits        int
+	TTL         time.Duration
+	PuzzleCache PuzzleCache
+	Resource    func(r *http.Request) string
+	Logger      Logger
+}
+
+// New - create new middleware
+func New(opts Opts) (*Middleware, error) {
+	issuer, err := puzzle.New(puzzle.Opts{
+		Bits:        opts.Bits,
+		TTL:         opts.TTL,
+		PuzzleCache: opts.PuzzleCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Middleware{
+		issuer:   issuer,
+		resource: opts.Resource,
+		logger:   opts.Logger,
+	}
+	if m.resource == nil {
+		m.resource = remoteIP
+	}
+
+	return m, nil
+}
+
+// Middleware - protects http handlers with proof of work
+// Request without valid stamp gets ChallengeStatus response with puzzle in ChallengeHeader.
+// Client solves puzzle and repeats request with solution in StampHeader,
+// each solution is accepted once
+type Middleware struct {
+	issuer   *puzzle.Issuer
+	resource func(r *http.Request) string
+	logger   Logger
+}
+
+// Handler - wrap handler with proof of work check
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := m.issuer.Redeem(r.Header.Get(StampHeader), m.resource(r)); err != nil {
+			m.challenge(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// challenge - send new puzzle to client
+func (m *Middleware) challenge(w http.ResponseWriter, r *http.Request, reason error) {
+	const op = "httppow.Middleware.challenge"
+
+	resource := m.resource(r)
+
+	challenge, err := m.issuer.Issue(resource)
+	if err != nil {
+		m.logError(err.Error(), "op", op, "resource", resource)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if !errors.Is(reason, ErrStampRequired) {
+		m.logInfo(reason.Error(), "resource", resource, "stamp", r.Header.Get(StampHeader))
+	}
+
+	w.Header().Set(ChallengeHeader, string(challenge))
+	http.Error(w, reason.Error(), ChallengeStatus)
+}
+
+func (m *Middleware) logInfo(msg string, args ...any) {
+	if m.logger != nil {
+		m.logger.Info(msg, args...)
+	}
+}
+
+func (m *Middleware) logError(msg string, args ...any) {
+	if m.logger != nil {
+		m.logger.Error(msg, args...)
+	}
+}
+
+// remoteIP - returns ip of request remote address
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,193 @@
+package httppow
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T, bits int, ttl time.Duration) *httptest.Server {
+	m, err := New(Opts{
+		Bits:        bits,
+		TTL:         ttl,
+		PuzzleCache: NewPuzzleCache(context.Background(), 0),
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte("ok:" + string(body)))
+	})
+
+	server := httptest.NewServer(m.Handler(handler))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func solve(t *testing.T, challenge string) string {
+	hashcash, err := hashcash.ParseHeader(challenge)
+	require.NoError(t, err)
+	require.NoError(t, hashcash.Compute(1000000))
+
+	return string(hashcash.Header())
+}
+
+func get(t *testing.T, url string, stamp string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if stamp != "" {
+		req.Header.Set(StampHeader, stamp)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func Test_New(t *testing.T) {
+	t.Run("zero bits", func(t *testing.T) {
+		_, err := New(Opts{PuzzleCache: NewPuzzleCache(context.Background(), 0)})
+		require.ErrorIs(t, err, ErrZeroBitsMustBeMoreThanZero)
+	})
+
+	t.Run("puzzle cache required", func(t *testing.T) {
+		_, err := New(Opts{Bits: 1})
+		require.ErrorIs(t, err, ErrPuzzleCacheRequired)
+	})
+}
+
+func Test_Middleware(t *testing.T) {
+	t.Run("challenge and solve ok", func(t *testing.T) {
+		server := newServer(t, 2, time.Minute)
+
+		resp := get(t, server.URL, "")
+		require.Equal(t, ChallengeStatus, resp.StatusCode)
+
+		challenge := resp.Header.Get(ChallengeHeader)
+		require.NotEmpty(t, challenge)
+		require.Contains(t, challenge, ":127.0.0.1:")
+
+		resp = get(t, server.URL, solve(t, challenge))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("stamp accepted once", func(t *testing.T) {
+		server := newServer(t, 2, time.Minute)
+
+		stamp := solve(t, get(t, server.URL, "").Header.Get(ChallengeHeader))
+		require.Equal(t, http.StatusOK, get(t, server.URL, stamp).StatusCode)
+
+		resp := get(t, server.URL, stamp)
+		require.Equal(t, ChallengeStatus, resp.StatusCode)
+		require.NotEmpty(t, resp.Header.Get(ChallengeHeader))
+
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, ErrStampNotFound.Error(), strings.TrimSpace(string(body)))
+	})
+
+	t.Run("stamp not solved", func(t *testing.T) {
+		server := newServer(t, 2, time.Minute)
+
+		challenge := get(t, server.URL, "").Header.Get(ChallengeHeader)
+		hashcash, err := hashcash.ParseHeader(challenge)
+		require.NoError(t, err)
+
+		for {
+			ok, err := hashcash.Header().IsHashCorrect(hashcash.Bits())
+			require.NoError(t, err)
+			if !ok {
+				break
+			}
+			require.NoError(t, hashcash.Compute(1))
+		}
+
+		resp := get(t, server.URL, string(hashcash.Header()))
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, ChallengeStatus, resp.StatusCode)
+		require.Equal(t, ErrStampNotCorrect.Error(), strings.TrimSpace(string(body)))
+	})
+
+	t.Run("stamp of other resource", func(t *testing.T) {
+		server := newServer(t, 2, time.Minute)
+
+		other, err := hashcash.New(2, "10.0.0.1")
+		require.NoError(t, err)
+
+		resp := get(t, server.URL, solve(t, string(other.Header())))
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, ChallengeStatus, resp.StatusCode)
+		require.Equal(t, ErrStampNotFound.Error(), strings.TrimSpace(string(body)))
+	})
+
+	t.Run("stamp expired", func(t *testing.T) {
+		server := newServer(t, 2, time.Millisecond)
+
+		stamp := solve(t, get(t, server.URL, "").Header.Get(ChallengeHeader))
+		time.Sleep(10 * time.Millisecond)
+
+		resp := get(t, server.URL, stamp)
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, ChallengeStatus, resp.StatusCode)
+		require.Equal(t, ErrStampExpired.Error(), strings.TrimSpace(string(body)))
+	})
+}
+
+func Test_Transport(t *testing.T) {
+	t.Run("get ok", func(t *testing.T) {
+		server := newServer(t, 2, time.Minute)
+		client := &http.Client{Transport: &Transport{}}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "ok:", string(body))
+	})
+
+	t.Run("post body repeated", func(t *testing.T) {
+		server := newServer(t, 2, time.Minute)
+		client := &http.Client{Transport: &Transport{}}
+
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "ok:payload", string(body))
+	})
+
+	t.Run("max attempts exceeded", func(t *testing.T) {
+		server := newServer(t, 6, time.Minute)
+		client := &http.Client{Transport: &Transport{MaxAttempts: 1}}
+
+		_, err := client.Get(server.URL)
+		require.ErrorIs(t, err, ErrChallengeNotSolved)
+	})
+
+	t.Run("not challenged response passed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(ChallengeStatus)
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: &Transport{}}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, ChallengeStatus, resp.StatusCode)
+	})
+}
@@ -0,0 +1,59 @@
+package httppow
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/puzzle"
+)
+
+// Transport - http.RoundTripper which solves challenges of Middleware transparently
+// Base - transport to send requests, http.DefaultTransport if nil.
+// MaxAttempts - max attempts to compute hashcash, puzzle default if value <= 0.
+// Requests with body are repeated only if body can be got again, see http.Request.GetBody
+type Transport struct {
+	Base        http.RoundTripper
+	MaxAttempts int
+}
+
+// RoundTrip - send request, solve challenge and repeat request with stamp if it's challenged
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := resp.Header.Get(ChallengeHeader)
+	if resp.StatusCode != ChallengeStatus || challenge == "" {
+		return resp, nil
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	stamp, err := puzzle.Solve(req.Context(), challenge, t.MaxAttempts)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set(StampHeader, stamp)
+
+	return t.base().RoundTrip(retry)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
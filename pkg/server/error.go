@@ -0,0 +1,16 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+)
+
+// Errors
+var (
+	ErrAddressRequired        = errors.New("listen address is required")
+	ErrBitsMustBeMoreThanZero = errors.New("bits must be more than zero")
+	ErrIncorrectAttempts      = hashcash.ErrIncorrectAttempts
+	ErrTTLMustBeMoreThanZero  = errors.New("ttl must be more than zero")
+	ErrIncorrectResource      = errors.New("resource must not contain line breaks")
+)
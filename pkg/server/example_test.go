@@ -0,0 +1,45 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/pvarentsov/powtcp/pkg/server"
+)
+
+func ExampleListen() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	s, err := server.Listen(ctx, ":8080",
+		server.WithBits(5),
+		server.WithTTL(time.Minute),
+		server.WithLogger(slog.Default()),
+		server.WithHandler(func(ctx context.Context, client server.Client) (string, error) {
+			return fmt.Sprintf("welcome, %s", client.Addr), nil
+		}),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	<-ctx.Done()
+	s.Shutdown()
+}
+
+func ExampleResources() {
+	s, err := server.Listen(context.Background(), "127.0.0.1:0",
+		server.WithResourceProvider(server.Resources("first", "second")),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	defer s.Shutdown()
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"time"
+)
+
+const (
+	defaultBits              = 5
+	defaultTTL               = time.Minute
+	defaultConnectionTimeout = 30 * time.Second
+	defaultShutdownTimeout   = time.Second
+)
+
+// Option - server option
+type Option func(*options)
+
+type options struct {
+	network             string
+	bits                int
+	attempts            float64
+	ttl                 time.Duration
+	resourceProvider    ResourceProvider
+	handler             Handler
+	puzzleStore         PuzzleStore
+	logger              *slog.Logger
+	tlsConfig           *tls.Config
+	connectionTimeout   time.Duration
+	shutdownTimeout     time.Duration
+	maxConnections      int
+	maxConnectionsPerIP int
+}
+
+// PuzzleStore - store of issued puzzles, e.g. shared by several servers
+// Puzzles are stored by key until expiration time
+// Take must atomically delete actual puzzle and report whether it was stored,
+// so solution is accepted once even if several servers share the store
+type PuzzleStore interface {
+	AddWithExp(k string, v struct{}, exp time.Time)
+	Take(k string) (ok bool)
+}
+
+// ResourceProvider - provider of resources sent to clients who passed proof of work
+type ResourceProvider interface {
+	Resource(ctx context.Context) (string, error)
+}
+
+// Handler - hook of resource step, runs instead of resource provider
+// Returned resource is sent to client, error is logged and client gets "internal error"
+type Handler func(ctx context.Context, client Client) (string, error)
+
+// Client - client who passed proof of work
+// TLS - uses if connection is served over tls
+type Client struct {
+	ID   string
+	Addr net.Addr
+	TLS  *tls.ConnectionState
+}
+
+// WithNetwork - set network to listen: tcp (default), tcp4, tcp6, unix or ws
+// Address is a socket path for unix, ws listens address and serves websocket on "/"
+func WithNetwork(network string) Option {
+	return func(o *options) {
+		o.network = network
+	}
+}
+
+// WithBits - set difficulty, number of zero bits in puzzles, defaultBits by default
+func WithBits(bits int) Option {
+	return func(o *options) {
+		o.bits = bits
+	}
+}
+
+// WithAttempts - set difficulty in expected attempts to solve puzzle, it overrides WithBits
+// Unlike bits, which multiply attempts by 16, it may be any number from 1, e.g. 100000
+func WithAttempts(attempts float64) Option {
+	return func(o *options) {
+		o.attempts = attempts
+	}
+}
+
+// WithTTL - set time to solve puzzle, defaultTTL by default
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithResourceProvider - set provider of resources, quotes by default
+func WithResourceProvider(provider ResourceProvider) Option {
+	return func(o *options) {
+		o.resourceProvider = provider
+	}
+}
+
+// WithHandler - set hook of resource step
+func WithHandler(handler Handler) Option {
+	return func(o *options) {
+		o.handler = handler
+	}
+}
+
+// WithPuzzleStore - set store of issued puzzles, in-memory cache by default
+func WithPuzzleStore(store PuzzleStore) Option {
+	return func(o *options) {
+		o.puzzleStore = store
+	}
+}
+
+// WithLogger - set logger, logs are discarded by default
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithTLS - serve connections over tls
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithConnectionTimeout - set max duration of connection, defaultConnectionTimeout by default
+func WithConnectionTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.connectionTimeout = timeout
+	}
+}
+
+// WithShutdownTimeout - set time to let connected clients finish on shutdown,
+// defaultShutdownTimeout by default
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithMaxConnections - limit concurrent connections globally and per ip, 0 - unlimited
+func WithMaxConnections(max int, maxPerIP int) Option {
+	return func(o *options) {
+		o.maxConnections = max
+		o.maxConnectionsPerIP = maxPerIP
+	}
+}
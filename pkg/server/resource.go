@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"strings"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/peer"
+)
+
+// Resources - provider of random resource from the list
+func Resources(resources ...string) ResourceProvider {
+	return resourceList(resources)
+}
+
+type resourceList []string
+
+func (l resourceList) Resource(ctx context.Context) (string, error) {
+	if len(l) == 0 {
+		return "", nil
+	}
+
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(l))))
+	if err != nil {
+		return "", err
+	}
+
+	return l[i.Int64()], nil
+}
+
+// resourceHandler - adapter of handler and resource provider to service
+type resourceHandler struct {
+	handler  Handler
+	provider ResourceProvider
+}
+
+func (h *resourceHandler) HandleResource(ctx context.Context, clientID string) (resource string, err error) {
+	if h.handler != nil {
+		p, _ := peer.FromContext(ctx)
+		resource, err = h.handler(ctx, Client{ID: clientID, Addr: p.Addr, TLS: p.TLS})
+	} else {
+		resource, err = h.provider.Resource(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Resource is sent as message payload which can't contain line breaks
+	if strings.ContainsAny(resource, "\r\n") {
+		return "", ErrIncorrectResource
+	}
+
+	return resource, nil
+}
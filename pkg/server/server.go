@@ -0,0 +1,201 @@
+// Package server - embeddable powtcp server
+//
+// Server gives resource to clients who solved proof of work puzzle:
+//
+//	s, err := server.Listen(ctx, ":8080",
+//		server.WithBits(5),
+//		server.WithHandler(func(ctx context.Context, client server.Client) (string, error) {
+//			return issueToken(client.ID)
+//		}),
+//	)
+//	defer s.Shutdown()
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/app/server"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/binding"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cache"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/ipfilter"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/quote"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/tcp"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/trust"
+	"github.com/pvarentsov/powtcp/internal/pkg/service"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Listen - listen address and serve clients in background
+// Context cancellation closes all connections immediately,
+// use Shutdown to close them gracefully
+func Listen(ctx context.Context, address string, opts ...Option) (*Server, error) {
+	o := options{
+		network:           "tcp",
+		bits:              defaultBits,
+		ttl:               defaultTTL,
+		connectionTimeout: defaultConnectionTimeout,
+		shutdownTimeout:   defaultShutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if address == "" {
+		return nil, ErrAddressRequired
+	}
+	if o.bits <= 0 {
+		return nil, ErrBitsMustBeMoreThanZero
+	}
+	if o.attempts != 0 && hashcash.ValidateAttempts(o.attempts) != nil {
+		return nil, ErrIncorrectAttempts
+	}
+	if o.ttl <= 0 {
+		return nil, ErrTTLMustBeMoreThanZero
+	}
+	if o.logger == nil {
+		o.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if o.resourceProvider == nil {
+		o.resourceProvider = Resources(quote.Quotes...)
+	}
+	if o.puzzleStore == nil {
+		o.puzzleStore = cache.New[string, struct{}](ctx, cache.Opts{
+			CleanInterval: o.ttl,
+			Logger:        o.logger,
+		})
+	}
+
+	// Trust policy and ip filter without lists let all clients in
+	trustPolicy, err := trust.New(trust.Opts{})
+	if err != nil {
+		return nil, err
+	}
+	ipFilter, err := ipfilter.New(ctx, ipfilter.Opts{})
+	if err != nil {
+		return nil, err
+	}
+	resourceBinder, err := binding.New(binding.Opts{Policy: binding.PolicyAddress})
+	if err != nil {
+		return nil, err
+	}
+
+	config := &config{address: address, options: o}
+
+	service := service.NewServer(service.ServerOpts{
+		Logger:          o.logger,
+		Config:          config,
+		PuzzleCache:     o.puzzleStore,
+		ErrorChecker:    tcp.NewConnErrorChecker(),
+		PuzzlePolicy:    trustPolicy,
+		FailureReporter: ipFilter,
+		ResourceBinder:  resourceBinder,
+		ResourceHandler: &resourceHandler{handler: o.handler, provider: o.resourceProvider},
+		Tracer:          noop.NewTracerProvider().Tracer(""),
+	})
+
+	srv, err := server.Listen(ctx, server.Opts{
+		Config:    config,
+		Logger:    o.logger,
+		Service:   service,
+		TLSConfig: o.tlsConfig,
+		IPFilter:  ipFilter,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{server: srv}, nil
+}
+
+// Server - embeddable powtcp server
+type Server struct {
+	server *server.Server
+}
+
+// Addr - returns listening address, e.g. to connect to ":0" address
+func (s *Server) Addr() string {
+	addr := s.server.Addrs()[0]
+	if _, after, ok := strings.Cut(addr, "://"); ok {
+		return after
+	}
+	return addr
+}
+
+// Shutdown - shutdown server gracefully
+// Server stops accepting connections and issuing new puzzles,
+// but lets connected clients redeem already issued puzzles.
+// Connections still open after shutdown timeout are closed forcibly.
+func (s *Server) Shutdown() {
+	s.server.Shutdown()
+}
+
+// config - adapter of options to app and service config interfaces
+type config struct {
+	address string
+	options
+}
+
+func (c *config) Address() string {
+	return c.address
+}
+
+func (c *config) Listeners() []server.ListenerConfig {
+	return []server.ListenerConfig{{Network: c.network, Address: c.address, Path: "/"}}
+}
+
+func (c *config) ShutdownTimeout() time.Duration {
+	return c.shutdownTimeout
+}
+
+func (c *config) ConnectionTimeout() time.Duration {
+	return c.connectionTimeout
+}
+
+func (c *config) MaxConnections() int {
+	return c.maxConnections
+}
+
+func (c *config) MaxConnectionsPerIP() int {
+	return c.maxConnectionsPerIP
+}
+
+func (c *config) ConnectionQueueSize() int {
+	return 0
+}
+
+func (c *config) ConnectionQueueTimeout() time.Duration {
+	return 0
+}
+
+func (c *config) ProxyProtocol() bool {
+	return false
+}
+
+func (c *config) TrustedProxyCIDRs() []string {
+	return nil
+}
+
+func (c *config) BackendAddress() string {
+	return ""
+}
+
+func (c *config) PuzzleTTL() time.Duration {
+	return c.ttl
+}
+
+func (c *config) PuzzleZeroBits() int {
+	return c.bits
+}
+
+func (c *config) PuzzleAttempts() float64 {
+	return c.attempts
+}
+
+func (c *config) TracePropagation() bool {
+	return false
+}
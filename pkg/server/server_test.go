@@ -0,0 +1,233 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/cache"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/hashcash"
+	"github.com/pvarentsov/powtcp/internal/pkg/lib/message"
+	"github.com/pvarentsov/powtcp/pkg/client"
+	"github.com/pvarentsov/powtcp/pkg/server"
+	"github.com/stretchr/testify/require"
+)
+
+type countingStore struct {
+	*cache.Cache[string, struct{}]
+	added atomic.Int64
+	taken atomic.Int64
+}
+
+func (s *countingStore) AddWithExp(k string, v struct{}, exp time.Time) {
+	s.added.Add(1)
+	s.Cache.AddWithExp(k, v, exp)
+}
+
+func (s *countingStore) Take(k string) bool {
+	s.taken.Add(1)
+	return s.Cache.Take(k)
+}
+
+func listen(t *testing.T, opts ...server.Option) *server.Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	s, err := server.Listen(ctx, "127.0.0.1:0", opts...)
+	require.NoError(t, err)
+	t.Cleanup(s.Shutdown)
+
+	return s
+}
+
+func fetch(t *testing.T, s *server.Server) (string, error) {
+	c, err := client.New(s.Addr())
+	require.NoError(t, err)
+
+	return c.Fetch(context.Background())
+}
+
+func Test_Listen(t *testing.T) {
+	t.Run("incorrect options", func(t *testing.T) {
+		ctx := context.Background()
+
+		_, err := server.Listen(ctx, "")
+		require.ErrorIs(t, err, server.ErrAddressRequired)
+
+		_, err = server.Listen(ctx, "127.0.0.1:0", server.WithBits(0))
+		require.ErrorIs(t, err, server.ErrBitsMustBeMoreThanZero)
+
+		_, err = server.Listen(ctx, "127.0.0.1:0", server.WithAttempts(0.5))
+		require.ErrorIs(t, err, server.ErrIncorrectAttempts)
+
+		_, err = server.Listen(ctx, "127.0.0.1:0", server.WithAttempts(1e60))
+		require.ErrorIs(t, err, server.ErrIncorrectAttempts)
+
+		_, err = server.Listen(ctx, "127.0.0.1:0", server.WithTTL(0))
+		require.ErrorIs(t, err, server.ErrTTLMustBeMoreThanZero)
+	})
+
+	t.Run("default resources ok", func(t *testing.T) {
+		s := listen(t, server.WithBits(2))
+
+		resource, err := fetch(t, s)
+		require.NoError(t, err)
+		require.NotEmpty(t, resource)
+	})
+
+	t.Run("attempts ok", func(t *testing.T) {
+		s := listen(t, server.WithAttempts(1000))
+
+		resource, err := fetch(t, s)
+		require.NoError(t, err)
+		require.NotEmpty(t, resource)
+	})
+
+	t.Run("resource provider ok", func(t *testing.T) {
+		s := listen(t, server.WithBits(2), server.WithResourceProvider(server.Resources("42")))
+
+		resource, err := fetch(t, s)
+		require.NoError(t, err)
+		require.Equal(t, "42", resource)
+	})
+
+	t.Run("handler ok", func(t *testing.T) {
+		var handled server.Client
+		s := listen(t,
+			server.WithBits(2),
+			server.WithResourceProvider(server.Resources("42")),
+			server.WithHandler(func(ctx context.Context, client server.Client) (string, error) {
+				handled = client
+				return "token:" + client.ID, nil
+			}),
+		)
+
+		resource, err := fetch(t, s)
+		require.NoError(t, err)
+		require.Equal(t, "token:"+handled.ID, resource)
+		require.Equal(t, handled.ID, handled.Addr.String())
+		require.Nil(t, handled.TLS)
+	})
+
+	t.Run("handler failed", func(t *testing.T) {
+		s := listen(t,
+			server.WithBits(2),
+			server.WithHandler(func(ctx context.Context, client server.Client) (string, error) {
+				return "", errors.New("database is down")
+			}),
+		)
+
+		_, err := fetch(t, s)
+		require.EqualError(t, err, "internal error")
+	})
+
+	t.Run("handler resource with line break", func(t *testing.T) {
+		s := listen(t,
+			server.WithBits(2),
+			server.WithHandler(func(ctx context.Context, client server.Client) (string, error) {
+				return "line\nbreak", nil
+			}),
+		)
+
+		_, err := fetch(t, s)
+		require.EqualError(t, err, "internal error")
+	})
+
+	t.Run("puzzle store ok", func(t *testing.T) {
+		store := &countingStore{Cache: cache.New[string, struct{}](context.Background(), cache.Opts{})}
+		s := listen(t, server.WithBits(2), server.WithPuzzleStore(store))
+
+		_, err := fetch(t, s)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), store.added.Load())
+		require.Equal(t, int64(1), store.taken.Load())
+		require.Empty(t, store.Keys())
+	})
+
+	t.Run("max connections", func(t *testing.T) {
+		s := listen(t, server.WithBits(10), server.WithMaxConnections(1, 0))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		busy, err := client.New(s.Addr())
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			busy.Fetch(ctx)
+			close(done)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		_, err = fetch(t, s)
+		require.ErrorIs(t, err, client.ErrTooManyConnections)
+
+		cancel()
+		<-done
+	})
+
+	t.Run("oversized message rejected", func(t *testing.T) {
+		s := listen(t, server.WithBits(1))
+
+		conn, err := net.Dial("tcp", s.Addr())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// Server stops reading before the end of message
+		go conn.Write([]byte("3:" + strings.Repeat("a", 2*message.MaxMessageSize)))
+
+		raw, err := message.ReadMessage(conn)
+		require.NoError(t, err)
+		reply, err := message.ParseMessage(raw)
+		require.NoError(t, err)
+		require.Equal(t, message.CommandError, reply.Command)
+		require.Equal(t, client.ErrMessageTooLarge.Error(), reply.Payload)
+	})
+
+	t.Run("shutdown closes connection blocked on write", func(t *testing.T) {
+		s := listen(t,
+			server.WithBits(1),
+			server.WithShutdownTimeout(50*time.Millisecond),
+			server.WithHandler(func(ctx context.Context, client server.Client) (string, error) {
+				return strings.Repeat("a", 64<<20), nil
+			}),
+		)
+
+		conn, err := net.Dial("tcp", s.Addr())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// Client requests resource and doesn't read it, so server write blocks
+		_, err = conn.Write(message.Message{Command: message.CommandRequestPuzzle}.Bytes())
+		require.NoError(t, err)
+		raw, err := message.ReadMessage(conn)
+		require.NoError(t, err)
+		puzzle, err := message.ParseMessage(raw)
+		require.NoError(t, err)
+
+		h, err := hashcash.ParseHeader(puzzle.Payload)
+		require.NoError(t, err)
+		require.NoError(t, h.Compute(1<<20))
+
+		_, err = conn.Write(message.Message{Command: message.CommandRequestResource, Payload: string(h.Header())}.Bytes())
+		require.NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+
+		done := make(chan struct{})
+		go func() {
+			s.Shutdown()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("shutdown is blocked by connection")
+		}
+	})
+}